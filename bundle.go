@@ -0,0 +1,467 @@
+package chainark
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * A Bundle carries a Unit, Recursive or Hybrid proof as one self-describing blob: the proof and its public witness,
+ * the fingerprint of the vk it verifies against, and the values decoded from the public witness. The binary format
+ * is "CARK", a version byte, then the fields in order, byte strings being prefixed by their uint32 length. The JSON
+ * format holds the same fields, hex encoded.
+ *
+ * The decoded values are a convenience for the receiver: ValidateBundle checks them against the public witness and
+ * a CircuitSet, VerifyBundle checks the proof too.
+ */
+
+const BundleVersion = 1
+
+var bundleMagic = [4]byte{'C', 'A', 'R', 'K'}
+
+var ErrBadBundle = errors.New("chainark: bad proof bundle")
+
+type ProofKind string
+
+const (
+	ProofKindUnit      ProofKind = "unit"
+	ProofKindRecursive ProofKind = "recursive"
+	ProofKindHybrid    ProofKind = "hybrid"
+)
+
+type Bundle struct {
+	Kind         ProofKind
+	Curve        ecc.ID // the curve of the proof
+	NbIDVals     int
+	BitsPerIDVal int
+	VkFp         common_utils.FingerPrintBytes
+
+	BeginID  LinkageIDBytes
+	EndID    LinkageIDBytes
+	SelfFps  []common_utils.FingerPrintBytes // placeholders for a unit proof
+	Count    uint64
+	HasCount bool
+
+	Proof   native_plonk.Proof
+	Witness witness.Witness // public only
+}
+
+// CircuitSet is the set of circuits of an application, which bundles are validated against.
+type CircuitSet struct {
+	NbIDVals     int
+	BitsPerIDVal int
+	NbIDBytes    int // the byte length of the ids, 0 when they take NbIDVals whole values
+	UnitFps      []common_utils.FingerPrintBytes
+	SelfFps      []common_utils.FingerPrintBytes // {recursiveFp} or {recursiveFp, hybridFp}
+	Count        bool                            // the units expose a ChainCount
+}
+
+// idLen returns the byte length of the ids of the set.
+func (s *CircuitSet) idLen() int {
+	if s.NbIDBytes != 0 {
+		return s.NbIDBytes
+	}
+	return s.NbIDVals * s.BitsPerIDVal / 8
+}
+
+// NewBundle bundles proof, verified by vk, decoding the values of pubWitness with the shape of set.
+func NewBundle[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	kind ProofKind, vk native_plonk.VerifyingKey, proof native_plonk.Proof, pubWitness witness.Witness, set *CircuitSet,
+) (*Bundle, error) {
+	vkFp, err := UnsafeFingerPrintFromVk[FR, G1El, G2El, GtEl](vk)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := DecodeChainWitness[FR](pubWitness, set.idLen(), set.BitsPerIDVal, len(set.SelfFps), set.Count)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := witnessCurve(pubWitness)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bundle{
+		Kind:         kind,
+		Curve:        curve,
+		NbIDVals:     set.NbIDVals,
+		BitsPerIDVal: set.BitsPerIDVal,
+		VkFp:         vkFp,
+		BeginID:      decoded.BeginID,
+		EndID:        decoded.EndID,
+		SelfFps:      decoded.SelfFps,
+		Count:        decoded.Count,
+		HasCount:     decoded.HasCount,
+		Proof:        proof,
+		Witness:      pubWitness,
+	}, nil
+}
+
+/**
+ * ValidateBundle checks, without verifying the proof, that:
+ * 1. the shape of b is the one of set;
+ * 2. the vk fingerprint is a unit one of set for a unit proof, the recursive or hybrid one otherwise;
+ * 3. the values decoded from the public witness are the ones of b, the SelfFps being those of set unless a unit proof.
+ */
+func ValidateBundle[FR emulated.FieldParams](b *Bundle, set *CircuitSet) error {
+	if b.NbIDVals != set.NbIDVals || b.BitsPerIDVal != set.BitsPerIDVal {
+		return fmt.Errorf("%w: shape %vx%v bits, expecting %vx%v bits", ErrWitnessShape,
+			b.NbIDVals, b.BitsPerIDVal, set.NbIDVals, set.BitsPerIDVal)
+	}
+
+	var fps []common_utils.FingerPrintBytes
+	switch b.Kind {
+	case ProofKindUnit:
+		fps = set.UnitFps
+	case ProofKindRecursive:
+		fps = set.SelfFps[:1]
+	case ProofKindHybrid:
+		if len(set.SelfFps) < 2 {
+			return fmt.Errorf("%w: no hybrid circuit in the set", ErrBadBundle)
+		}
+		fps = set.SelfFps[1:2]
+	default:
+		return fmt.Errorf("%w: kind %q", ErrBadBundle, b.Kind)
+	}
+	found := false
+	for i := 0; i < len(fps); i++ {
+		if fpBytesEqual(b.VkFp, fps[i]) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrUnknownVkFp
+	}
+
+	decoded, err := DecodeChainWitness[FR](b.Witness, set.idLen(), set.BitsPerIDVal, len(set.SelfFps), set.Count)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(decoded.BeginID, b.BeginID) {
+		return ErrBeginIDMismatch
+	}
+	if !bytes.Equal(decoded.EndID, b.EndID) {
+		return ErrEndIDMismatch
+	}
+	if decoded.HasCount != b.HasCount || decoded.Count != b.Count {
+		return fmt.Errorf("%w: count", ErrBadBundle)
+	}
+	if len(b.SelfFps) != len(decoded.SelfFps) {
+		return fmt.Errorf("%w: %v self fingerprints", ErrSelfFpsMismatch, len(b.SelfFps))
+	}
+	for i := 0; i < len(decoded.SelfFps); i++ {
+		if !fpBytesEqual(decoded.SelfFps[i], b.SelfFps[i]) {
+			return fmt.Errorf("%w: at index %v", ErrSelfFpsMismatch, i)
+		}
+		if b.Kind != ProofKindUnit && !fpBytesEqual(decoded.SelfFps[i], set.SelfFps[i]) {
+			return fmt.Errorf("%w: at index %v", ErrSelfFpsMismatch, i)
+		}
+	}
+	return nil
+}
+
+// VerifyBundle validates b against set, then verifies its proof with vk, whose fingerprint must be the one of b.
+func VerifyBundle[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	b *Bundle, vk native_plonk.VerifyingKey, set *CircuitSet,
+) error {
+	err := ValidateBundle[FR](b, set)
+	if err != nil {
+		return err
+	}
+	vkFp, err := UnsafeFingerPrintFromVk[FR, G1El, G2El, GtEl](vk)
+	if err != nil {
+		return err
+	}
+	if !fpBytesEqual(vkFp, b.VkFp) {
+		return ErrUnknownVkFp
+	}
+
+	var fr FR
+	err = native_plonk.Verify(b.Proof, vk, b.Witness, plonk.GetNativeVerifierOptions(outerField[FR](), fr.Modulus()))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	return nil
+}
+
+func (b *Bundle) MarshalBinary() ([]byte, error) {
+	proof, w, err := b.encodeProof()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(bundleMagic[:])
+	buf.WriteByte(BundleVersion)
+	writeBytes(&buf, []byte(b.Kind))
+	writeUint(&buf, uint64(b.Curve))
+	writeUint(&buf, uint64(b.NbIDVals))
+	writeUint(&buf, uint64(b.BitsPerIDVal))
+	writeBytes(&buf, b.VkFp)
+	writeBytes(&buf, b.BeginID)
+	writeBytes(&buf, b.EndID)
+	writeUint(&buf, uint64(len(b.SelfFps)))
+	for _, fp := range b.SelfFps {
+		writeBytes(&buf, fp)
+	}
+	if b.HasCount {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	writeUint(&buf, b.Count)
+	writeBytes(&buf, proof)
+	writeBytes(&buf, w)
+	return buf.Bytes(), nil
+}
+
+func (b *Bundle) UnmarshalBinary(data []byte) error {
+	r := &bundleReader{data: data}
+	magic := r.next(len(bundleMagic))
+	if r.err == nil && !bytes.Equal(magic, bundleMagic[:]) {
+		return fmt.Errorf("%w: not a bundle", ErrBadBundle)
+	}
+	version := r.next(1)
+	if r.err == nil && version[0] != BundleVersion {
+		return fmt.Errorf("%w: version %v", ErrBadBundle, version[0])
+	}
+
+	var ret Bundle
+	ret.Kind = ProofKind(r.bytes())
+	ret.Curve = ecc.ID(r.uint())
+	ret.NbIDVals = int(r.uint())
+	ret.BitsPerIDVal = int(r.uint())
+	ret.VkFp = r.bytes()
+	ret.BeginID = r.bytes()
+	ret.EndID = r.bytes()
+	nbSelfFps := r.uint()
+	for i := uint64(0); i < nbSelfFps && r.err == nil; i++ {
+		ret.SelfFps = append(ret.SelfFps, r.bytes())
+	}
+	hasCount := r.next(1)
+	ret.HasCount = r.err == nil && hasCount[0] == 1
+	ret.Count = r.uint()
+	proof := r.bytes()
+	w := r.bytes()
+	if r.err != nil {
+		return fmt.Errorf("%w: %v", ErrBadBundle, r.err)
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("%w: %v trailing bytes", ErrBadBundle, len(r.data))
+	}
+
+	err := ret.decodeProof(proof, w)
+	if err != nil {
+		return err
+	}
+	*b = ret
+	return nil
+}
+
+type bundleJSON struct {
+	Version      int      `json:"version"`
+	Kind         string   `json:"kind"`
+	Curve        string   `json:"curve"`
+	NbIDVals     int      `json:"nbIdVals"`
+	BitsPerIDVal int      `json:"bitsPerIdVal"`
+	VkFp         string   `json:"vkFp"`
+	BeginID      string   `json:"beginId"`
+	EndID        string   `json:"endId"`
+	SelfFps      []string `json:"selfFps"`
+	Count        *uint64  `json:"count,omitempty"`
+	Proof        string   `json:"proof"`
+	Witness      string   `json:"witness"`
+}
+
+func (b *Bundle) MarshalJSON() ([]byte, error) {
+	proof, w, err := b.encodeProof()
+	if err != nil {
+		return nil, err
+	}
+
+	v := bundleJSON{
+		Version:      BundleVersion,
+		Kind:         string(b.Kind),
+		Curve:        b.Curve.String(),
+		NbIDVals:     b.NbIDVals,
+		BitsPerIDVal: b.BitsPerIDVal,
+		VkFp:         hex.EncodeToString(b.VkFp),
+		BeginID:      hex.EncodeToString(b.BeginID),
+		EndID:        hex.EncodeToString(b.EndID),
+		SelfFps:      make([]string, len(b.SelfFps)),
+		Proof:        hex.EncodeToString(proof),
+		Witness:      hex.EncodeToString(w),
+	}
+	for i, fp := range b.SelfFps {
+		v.SelfFps[i] = hex.EncodeToString(fp)
+	}
+	if b.HasCount {
+		v.Count = &b.Count
+	}
+	return json.Marshal(v)
+}
+
+func (b *Bundle) UnmarshalJSON(data []byte) error {
+	var v bundleJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadBundle, err)
+	}
+	if v.Version != BundleVersion {
+		return fmt.Errorf("%w: version %v", ErrBadBundle, v.Version)
+	}
+	curve, err := ecc.IDFromString(v.Curve)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadBundle, err)
+	}
+
+	ret := Bundle{
+		Kind:         ProofKind(v.Kind),
+		Curve:        curve,
+		NbIDVals:     v.NbIDVals,
+		BitsPerIDVal: v.BitsPerIDVal,
+	}
+	hexFields := []struct {
+		name  string
+		value string
+		dst   *[]byte
+	}{
+		{"vkFp", v.VkFp, (*[]byte)(&ret.VkFp)},
+		{"beginId", v.BeginID, (*[]byte)(&ret.BeginID)},
+		{"endId", v.EndID, (*[]byte)(&ret.EndID)},
+	}
+	for _, f := range hexFields {
+		*f.dst, err = hex.DecodeString(f.value)
+		if err != nil {
+			return fmt.Errorf("%w: %v: %v", ErrBadBundle, f.name, err)
+		}
+	}
+	for i, s := range v.SelfFps {
+		fp, err := hex.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("%w: selfFps[%v]: %v", ErrBadBundle, i, err)
+		}
+		ret.SelfFps = append(ret.SelfFps, fp)
+	}
+	if v.Count != nil {
+		ret.Count, ret.HasCount = *v.Count, true
+	}
+	proof, err := hex.DecodeString(v.Proof)
+	if err != nil {
+		return fmt.Errorf("%w: proof: %v", ErrBadBundle, err)
+	}
+	w, err := hex.DecodeString(v.Witness)
+	if err != nil {
+		return fmt.Errorf("%w: witness: %v", ErrBadBundle, err)
+	}
+
+	err = ret.decodeProof(proof, w)
+	if err != nil {
+		return err
+	}
+	*b = ret
+	return nil
+}
+
+func (b *Bundle) encodeProof() ([]byte, []byte, error) {
+	if b.Proof == nil || b.Witness == nil {
+		return nil, nil, fmt.Errorf("%w: missing proof or witness", ErrBadBundle)
+	}
+	var proof bytes.Buffer
+	_, err := b.Proof.WriteTo(&proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := b.Witness.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof.Bytes(), w, nil
+}
+
+func (b *Bundle) decodeProof(proof, w []byte) (err error) {
+	// gnark panics on unknown curves
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: curve %v", ErrBadBundle, b.Curve)
+		}
+	}()
+
+	b.Proof = native_plonk.NewProof(b.Curve)
+	_, err = b.Proof.ReadFrom(bytes.NewReader(proof))
+	if err != nil {
+		return fmt.Errorf("%w: proof: %v", ErrBadBundle, err)
+	}
+	b.Witness, err = witness.New(b.Curve.ScalarField())
+	if err != nil {
+		return err
+	}
+	err = b.Witness.UnmarshalBinary(w)
+	if err != nil {
+		return fmt.Errorf("%w: witness: %v", ErrBadBundle, err)
+	}
+	return nil
+}
+
+func writeUint(w *bytes.Buffer, v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	w.Write(buf[:])
+}
+
+func writeBytes(w *bytes.Buffer, data []byte) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(len(data)))
+	w.Write(buf[:])
+	w.Write(data)
+}
+
+// bundleReader reads the fields of a bundle, keeping the first error.
+type bundleReader struct {
+	data []byte
+	err  error
+}
+
+func (r *bundleReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	ret := r.data[:n]
+	r.data = r.data[n:]
+	return ret
+}
+
+func (r *bundleReader) uint() uint64 {
+	data := r.next(8)
+	if data == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+func (r *bundleReader) bytes() []byte {
+	data := r.next(4)
+	if data == nil {
+		return nil
+	}
+	ret := r.next(int(binary.BigEndian.Uint32(data)))
+	return append([]byte(nil), ret...)
+}
@@ -0,0 +1,72 @@
+package chainark
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+func TestBundle(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit(assert, 1, 0)
+	vk, fp := unit.vk, unit.fp
+	beginID, endID := unit.ids[0], unit.ids[1]
+
+	// carrying its own fingerprint, the unit proof passes for a recursive one
+	proof, pubWitness := unit.proveNative(assert, unit.link(0, fp))
+
+	set := &CircuitSet{NbIDVals: 2, BitsPerIDVal: 128, SelfFps: []common_utils.FingerPrintBytes{fp}}
+	verify := func(b *Bundle, set *CircuitSet) error {
+		return VerifyBundle[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](b, vk, set)
+	}
+
+	b, err := NewBundle[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		ProofKindRecursive, vk, proof, pubWitness, set)
+	assert.NoError(err)
+	assert.Equal(ecc.BN254, b.Curve)
+	assert.Equal(beginID, b.BeginID)
+	assert.Equal(endID, b.EndID)
+	assert.NoError(verify(b, set))
+
+	// both formats round trip
+	data, err := b.MarshalBinary()
+	assert.NoError(err)
+	var fromBinary Bundle
+	assert.NoError(fromBinary.UnmarshalBinary(data))
+	assert.NoError(verify(&fromBinary, set))
+	assert.Equal(b.VkFp, fromBinary.VkFp)
+	assert.Equal(b.EndID, fromBinary.EndID)
+
+	data, err = json.Marshal(b)
+	assert.NoError(err)
+	var fromJSON Bundle
+	assert.NoError(json.Unmarshal(data, &fromJSON))
+	assert.NoError(verify(&fromJSON, set))
+	assert.Equal(b.SelfFps, fromJSON.SelfFps)
+
+	// a unit proof is validated against the unit fingerprints
+	unitSet := &CircuitSet{NbIDVals: 2, BitsPerIDVal: 128, UnitFps: []common_utils.FingerPrintBytes{fp},
+		SelfFps: []common_utils.FingerPrintBytes{GetPlaceholderFp()}}
+	fromJSON.Kind = ProofKindUnit
+	assert.NoError(verify(&fromJSON, unitSet))
+
+	assert.True(errors.Is(verify(b, unitSet), ErrUnknownVkFp))
+	assert.True(errors.Is(verify(&fromJSON, set), ErrUnknownVkFp))
+	fromJSON.Kind = ProofKindHybrid
+	assert.True(errors.Is(verify(&fromJSON, set), ErrBadBundle))
+	fromBinary.EndID = beginID
+	assert.True(errors.Is(verify(&fromBinary, set), ErrEndIDMismatch))
+	assert.True(errors.Is(verify(b, &CircuitSet{NbIDVals: 4, BitsPerIDVal: 64, SelfFps: set.SelfFps}), ErrWitnessShape))
+
+	data, err = b.MarshalBinary()
+	assert.NoError(err)
+	var bad Bundle
+	assert.True(errors.Is(bad.UnmarshalBinary(data[:len(data)-1]), ErrBadBundle))
+	data[4] = BundleVersion + 1
+	assert.True(errors.Is(bad.UnmarshalBinary(data), ErrBadBundle))
+}
@@ -0,0 +1,318 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/planner"
+	"github.com/lightec-xyz/chainark/proofstore"
+	"github.com/lightec-xyz/common/operations"
+)
+
+type testUnitCircuit struct {
+	*chainark.MultiUnit[FR, G1El, G2El, GtEl]
+}
+
+func (c *testUnitCircuit) Define(api frontend.API) error {
+	x := api.Mul(c.BeginID.Vals[0], c.EndID.Vals[0])
+	api.AssertIsDifferent(api.Add(x, c.BeginID.Vals[1], 7), c.EndID.Vals[1])
+	return c.MultiUnit.Define(api)
+}
+
+func testRegistry(assert *test.Assert) *Registry {
+	r := NewRegistry()
+	err := r.RegisterUnit("test", UnitFactory{
+		Circuit: func(shape Shape, params json.RawMessage) (frontend.Circuit, error) {
+			return &testUnitCircuit{
+				MultiUnit: chainark.NewMultiUnitCircuit[FR, G1El, G2El, GtEl](shape.NbIDVals, shape.BitsPerIDVal, shape.NbSelfFps),
+			}, nil
+		},
+		Assignment: func(shape Shape, params json.RawMessage, beginID, endID chainark.LinkageIDBytes) (frontend.Circuit, error) {
+			return &testUnitCircuit{
+				MultiUnit: chainark.NewMultiUnitAssignment[FR, G1El, G2El, GtEl](beginID, endID, shape.BitsPerIDVal, shape.NbSelfFps),
+			}, nil
+		},
+	})
+	assert.NoError(err)
+	return r
+}
+
+func writeConfig(assert *test.Assert, dir string, config Config) string {
+	data, err := json.Marshal(config)
+	assert.NoError(err)
+	path := filepath.Join(dir, defaultConfigFile)
+	assert.NoError(os.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestRegistry(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	r := testRegistry(assert)
+	err := r.RegisterUnit("test", r.units["test"])
+	assert.True(errors.Is(err, ErrDuplicateName))
+	err = r.RegisterComponent("test", ComponentFactory{})
+	assert.True(errors.Is(err, ErrIncompleteFactory))
+
+	config := Config{
+		NbIDVals:     2,
+		BitsPerIDVal: 128,
+		Units:        []UnitConfig{{Name: "unit", Circuit: "unknown"}},
+	}
+	assert.True(errors.Is(config.Validate(r), ErrNotRegistered))
+
+	config.Units[0].Circuit = "test"
+	assert.NoError(config.Validate(r))
+
+	config.NbIDVals, config.BitsPerIDVal = 3, 60
+	assert.True(errors.Is(config.Validate(r), ErrBadConfig))
+	// 160-bit addresses on 60-bit values, with a top value of 40 bits
+	config.NbIDBytes = 20
+	assert.NoError(config.Validate(r))
+	assert.Equal(20, config.idLen())
+	config.NbIDBytes = 23
+	assert.True(errors.Is(config.Validate(r), ErrBadConfig))
+	config.NbIDVals, config.BitsPerIDVal, config.NbIDBytes = 2, 128, 0
+
+	config.Units = append(config.Units, UnitConfig{Name: recursiveName, Circuit: "test"})
+	assert.True(errors.Is(config.Validate(r), ErrBadConfig))
+}
+
+func TestUsage(t *testing.T) {
+	assert := test.NewAssert(t)
+	r := testRegistry(assert)
+
+	var out bytes.Buffer
+	assert.True(errors.Is(Run(r, nil, &out), ErrUsage))
+	assert.True(errors.Is(Run(r, []string{"unknown"}, &out), ErrUsage))
+	assert.True(errors.Is(Run(r, []string{"prove-unit", "-unit", "unit"}, &out), ErrUsage))
+	assert.True(errors.Is(Run(r, []string{"setup", "extra"}, &out), ErrUsage))
+}
+
+func TestProveUnit(t *testing.T) {
+	assert := test.NewAssert(t)
+	r := testRegistry(assert)
+
+	path := writeConfig(assert, t.TempDir(), Config{
+		DataDir:      "data",
+		NbIDVals:     2,
+		BitsPerIDVal: 128,
+		Units:        []UnitConfig{{Name: "unit", Circuit: "test"}},
+	})
+	assert.NoError(os.Mkdir(filepath.Join(filepath.Dir(path), "data"), 0755))
+
+	var out bytes.Buffer
+	err := Run(r, []string{"setup", "-config", path, "-units-only", "-unsafe-srs"}, &out)
+	assert.NoError(err)
+
+	begin := "843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85"
+	end := "6bb396a01d83bfa27c7476005eacb6dfd2384fc70a016ce2ee145a28288c234c"
+	err = Run(r, []string{"prove-unit", "-config", path, "-unit", "unit", "-begin", begin, "-end", end, "-out", "unit_0_1"}, &out)
+	assert.NoError(err)
+
+	// ids of the wrong length are rejected before proving
+	err = Run(r, []string{"prove-unit", "-config", path, "-unit", "unit", "-begin", begin[:32], "-end", end, "-out", "bad"}, &out)
+	assert.True(errors.Is(err, ErrUsage))
+
+	// a Prover also puts its proofs in the proof store of the config
+	storeConfig, err := LoadConfig(path)
+	assert.NoError(err)
+	storeConfig.ProofStore = "store"
+	prover, err := NewProver(r, storeConfig, &out)
+	assert.NoError(err)
+	beginID, err := hex.DecodeString(begin)
+	assert.NoError(err)
+	endID, err := hex.DecodeString(end)
+	assert.NoError(err)
+	err = prover.Prove(context.Background(), &planner.Job{
+		Kind: planner.KindUnit, Circuit: "unit", Begin: 0, End: 1, BeginID: beginID, EndID: endID, Output: "unit_0_1",
+	})
+	assert.NoError(err)
+	store, err := proofstore.OpenFileStore(filepath.Join(filepath.Dir(path), "data", "store"))
+	assert.NoError(err)
+	e, err := store.Exact(0, 1)
+	assert.NoError(err)
+	assert.Equal("unit", e.Meta.Circuit)
+	assert.Equal(chainark.LinkageIDBytes(endID), e.Meta.EndID)
+
+	out.Reset()
+	err = Run(r, []string{"inspect", "-config", path, "-proof", "unit_0_1"}, &out)
+	assert.NoError(err)
+	assert.True(strings.Contains(out.String(), "begin: "+begin))
+	assert.True(strings.Contains(out.String(), "end: "+end))
+
+	out.Reset()
+	err = Run(r, []string{"inspect", "-config", path}, &out)
+	assert.NoError(err)
+	lines := strings.Split(out.String(), "\n")
+	_, err = hex.DecodeString(strings.TrimPrefix(lines[0], "unit: "))
+	assert.NoError(err)
+	assert.Equal("recursive: not set up", lines[1])
+
+	// bundling needs the fingerprint of the recursive circuit, any vk would do for a unit proof
+	dataDir := filepath.Join(filepath.Dir(path), "data")
+	vk, err := os.ReadFile(filepath.Join(dataDir, "unit.vk"))
+	assert.NoError(err)
+	assert.NoError(os.WriteFile(filepath.Join(dataDir, "recursive.vk"), vk, 0644))
+
+	// keys not in the manifest written by setup are refused
+	err = Run(r, []string{"bundle", "-config", path, "-proof", "unit_0_1", "-circuit", "unit"}, &out)
+	assert.True(errors.Is(err, chainark.ErrManifestMismatch))
+	config, err := LoadConfig(path)
+	assert.NoError(err)
+	manifest, err := config.readManifest()
+	assert.NoError(err)
+	unit, err := manifest.Circuit("unit")
+	assert.NoError(err)
+	recursive := *unit
+	recursive.Kind, recursive.Name = chainark.ProofKindRecursive, recursiveName
+	manifest.Set(recursive)
+	assert.NoError(config.writeManifest(manifest))
+
+	for _, format := range [][]string{nil, {"-json"}} {
+		err = Run(r, append([]string{"bundle", "-config", path, "-proof", "unit_0_1", "-circuit", "unit"}, format...), &out)
+		assert.NoError(err)
+		out.Reset()
+		in := filepath.Join(dataDir, "unit_0_1.bundle")
+		if format != nil {
+			in += ".json"
+		}
+		err = Run(r, append([]string{"verify-bundle", "-config", path, "-in", in}, format...), &out)
+		assert.NoError(err)
+		assert.True(strings.Contains(out.String(), "unit proof of unit verified: "+begin+" -> "+end))
+	}
+	err = Run(r, []string{"verify-bundle", "-config", path, "-in", filepath.Join(dataDir, "unit_0_1.bundle.json")}, &out)
+	assert.True(errors.Is(err, chainark.ErrBadBundle))
+
+	unit, err = manifest.Circuit("unit")
+	assert.NoError(err)
+	unit.VkHash = append([]byte{unit.VkHash[0] ^ 1}, unit.VkHash[1:]...)
+	assert.NoError(config.writeManifest(manifest))
+	err = Run(r, []string{"prove-unit", "-config", path, "-unit", "unit", "-begin", begin, "-end", end, "-out", "unit_0_1"}, &out)
+	assert.True(errors.Is(err, chainark.ErrManifestMismatch))
+
+	// so are keys without a manifest, unless the config opts out
+	assert.NoError(os.Remove(config.manifestFile()))
+	err = Run(r, []string{"prove-unit", "-config", path, "-unit", "unit", "-begin", begin, "-end", end, "-out", "unit_0_1"}, &out)
+	assert.True(errors.Is(err, chainark.ErrManifestMismatch))
+	config.NoManifest = true
+	path = writeConfig(assert, filepath.Dir(path), *config)
+	err = Run(r, []string{"prove-unit", "-config", path, "-unit", "unit", "-begin", begin, "-end", end, "-out", "unit_0_1"}, &out)
+	assert.NoError(err)
+}
+
+func TestSetupOptimization(t *testing.T) {
+	assert := test.NewAssert(t)
+	r := testRegistry(assert)
+
+	// a unit circuit with a larger domain than the test one
+	err := r.RegisterUnit("large", UnitFactory{
+		Circuit: func(shape Shape, params json.RawMessage) (frontend.Circuit, error) {
+			return &largeUnitCircuit{testUnitCircuit{
+				MultiUnit: chainark.NewMultiUnitCircuit[FR, G1El, G2El, GtEl](shape.NbIDVals, shape.BitsPerIDVal, shape.NbSelfFps),
+			}}, nil
+		},
+		Assignment: r.units["test"].Assignment,
+	})
+	assert.NoError(err)
+
+	path := writeConfig(assert, t.TempDir(), Config{
+		DataDir:      "data",
+		NbIDVals:     2,
+		BitsPerIDVal: 128,
+		Optimization: true,
+		Units:        []UnitConfig{{Name: "unit", Circuit: "test"}, {Name: "large", Circuit: "large"}},
+	})
+	dataDir := filepath.Join(filepath.Dir(path), "data")
+	assert.NoError(os.Mkdir(dataDir, 0755))
+
+	// setup fails before writing any key
+	var out bytes.Buffer
+	err = Run(r, []string{"setup", "-config", path, "-units-only", "-unsafe-srs"}, &out)
+	assert.True(errors.Is(err, chainark.ErrDomainSize))
+	assert.True(strings.Contains(err.Error(), "unit needs"))
+	entries, err := os.ReadDir(dataDir)
+	assert.NoError(err)
+	assert.Equal(0, len(entries))
+}
+
+type largeUnitCircuit struct {
+	testUnitCircuit
+}
+
+func (c *largeUnitCircuit) Define(api frontend.API) error {
+	x := c.BeginID.Vals[0]
+	for i := 0; i < 4096; i++ {
+		x = api.Mul(x, x)
+	}
+	return c.testUnitCircuit.Define(api)
+}
+
+func TestSetupSRS(t *testing.T) {
+	assert := test.NewAssert(t)
+	r := testRegistry(assert)
+
+	path := writeConfig(assert, t.TempDir(), Config{
+		DataDir:      "data",
+		SRSDir:       "srs",
+		NbIDVals:     2,
+		BitsPerIDVal: 128,
+		Units:        []UnitConfig{{Name: "unit", Circuit: "test"}},
+	})
+	dataDir := filepath.Join(filepath.Dir(path), "data")
+	assert.NoError(os.Mkdir(dataDir, 0755))
+	srsDir := filepath.Join(filepath.Dir(path), "srs")
+	assert.NoError(os.Mkdir(srsDir, 0755))
+
+	// the unsafe SRS is only used on demand, with a warning
+	var out bytes.Buffer
+	cfg, err := LoadConfig(path)
+	assert.NoError(err)
+	cfg.SRSDir = ""
+	data, err := json.Marshal(cfg)
+	assert.NoError(err)
+	noSRSPath := filepath.Join(filepath.Dir(path), "nosrs.json")
+	assert.NoError(os.WriteFile(noSRSPath, data, 0644))
+	err = Run(r, []string{"setup", "-config", noSRSPath, "-units-only"}, &out)
+	assert.True(errors.Is(err, ErrUsage))
+	err = Run(r, []string{"setup", "-config", path, "-units-only", "-unsafe-srs"}, &out)
+	assert.NoError(err)
+	assert.True(strings.Contains(out.String(), "WARNING"))
+
+	// an SRS of the size of the unit circuit, in the files of the srs dir
+	ccs, err := operations.ReadCcs(filepath.Join(dataDir, "unit.ccs"))
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+	size := ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints() + ccs.GetNbPublicVariables()))
+	for ext, v := range map[string]io.WriterTo{"srs": srs, "lsrs": srsLagrange} {
+		f, err := os.Create(filepath.Join(srsDir, fmt.Sprintf("bn254_pow_%v.%v", bits.TrailingZeros64(size), ext)))
+		assert.NoError(err)
+		_, err = v.WriteTo(f)
+		assert.NoError(err)
+		assert.NoError(f.Close())
+	}
+
+	out.Reset()
+	err = Run(r, []string{"setup", "-config", path, "-units-only"}, &out)
+	assert.NoError(err)
+	assert.False(strings.Contains(out.String(), "WARNING"))
+
+	err = Run(r, []string{"setup", "-config", path, "-units-only", "-srs-dir", dataDir}, &out)
+	assert.True(errors.Is(err, os.ErrNotExist))
+}
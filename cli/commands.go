@@ -0,0 +1,839 @@
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/follower"
+	"github.com/lightec-xyz/chainark/orchestrator"
+	"github.com/lightec-xyz/chainark/planner"
+	"github.com/lightec-xyz/common/operations"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+const defaultConfigFile = "chainark.json"
+
+type command struct {
+	usage string
+	run   func(r *Registry, args []string, out io.Writer) error
+}
+
+var commands = map[string]command{
+	"setup":           {"setup [-units-only] [-srs-dir dir | -unsafe-srs]", runSetup},
+	"prove-unit":      {"prove-unit -unit name -begin hex -end hex -out name", runProveUnit},
+	"prove-recursive": {"prove-recursive -first name -first-circuit unit|recursive|hybrid -second name -second-unit unit -begin hex -relay hex -end hex -out name", runProveRecursive},
+	"prove-hybrid":    {"prove-hybrid -first name -first-circuit unit|recursive|hybrid -begin hex -relay hex -end hex -out name", runProveHybrid},
+	"verify":          {"verify -proof name [-circuit recursive|hybrid] -begin hex -end hex", runVerify},
+	"inspect":         {"inspect [-proof name]", runInspect},
+	"bundle":          {"bundle -proof name -circuit name [-json] [-out file]", runBundle},
+	"verify-bundle":   {"verify-bundle -in file [-json]", runVerifyBundle},
+	"plan":            {"plan -ids file", runPlan},
+	"run":             {"run -ids file [-workers n] [-retries n]", runRun},
+	"follow":          {"follow -ids file [-checkpoint file] [-interval duration] [-batch n] [-workers n] [-retries n]", runFollow},
+}
+
+var commandOrder = []string{"setup", "prove-unit", "prove-recursive", "prove-hybrid", "verify", "inspect", "bundle", "verify-bundle", "plan", "run", "follow"}
+
+// Main runs the command line tool with the application circuits registered in r, returning the exit code.
+func Main(r *Registry, args []string) int {
+	err := Run(r, args, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, ErrUsage) {
+			Usage(os.Stderr)
+		}
+		return 1
+	}
+	return 0
+}
+
+// Run runs the subcommand args[0] with its flags args[1:]. Every subcommand accepts -config, the path of the
+// config file, defaulting to chainark.json.
+func Run(r *Registry, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing subcommand", ErrUsage)
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown subcommand %v", ErrUsage, args[0])
+	}
+	return cmd.run(r, args[1:], out)
+}
+
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: chainark <subcommand> [-config chainark.json] [flags]")
+	for _, name := range commandOrder {
+		fmt.Fprintf(w, "  chainark %v\n", commands[name].usage)
+	}
+}
+
+type flagSet struct {
+	*flag.FlagSet
+	config *string
+}
+
+func newFlagSet(name string) *flagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return &flagSet{
+		FlagSet: fs,
+		config:  fs.String("config", defaultConfigFile, "path of the config file"),
+	}
+}
+
+// load parses args, ensures the required flags are set, then loads and validates the config.
+func (fs *flagSet) load(r *Registry, args []string, out io.Writer, required ...string) (*session, error) {
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v: %v", ErrUsage, fs.Name(), err)
+	}
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("%w: %v: unexpected arguments %v", ErrUsage, fs.Name(), fs.Args())
+	}
+	for _, name := range required {
+		if fs.Lookup(name).Value.String() == "" {
+			return nil, fmt.Errorf("%w: %v: -%v is required", ErrUsage, fs.Name(), name)
+		}
+	}
+
+	config, err := LoadConfig(*fs.config)
+	if err != nil {
+		return nil, err
+	}
+	err = config.Validate(r)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(r, config, out), nil
+}
+
+func runSetup(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("setup")
+	unitsOnly := fs.Bool("units-only", false, "only set up the unit circuits")
+	srsDir := fs.String("srs-dir", "", "directory of the SRS files bn254_pow_<n>.srs and .lsrs, defaulting to srsDir of the config")
+	unsafeSRS := fs.Bool("unsafe-srs", false, "set up with an SRS of known toxic waste, for tests only")
+	s, err := fs.load(r, args, out)
+	if err != nil {
+		return err
+	}
+	newSRS, err := s.srsLoader(*srsDir, *unsafeSRS)
+	if err != nil {
+		return err
+	}
+	config := s.config
+	shape := config.shape()
+
+	// the circuits set up before are kept in the manifest, unless of another shape
+	manifest, err := config.readManifest()
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, chainark.ErrManifestMismatch) {
+		manifest, err = &chainark.Manifest{
+			NbIDVals: config.NbIDVals, BitsPerIDVal: config.BitsPerIDVal, NbIDBytes: config.NbIDBytes, Count: config.Count,
+		}, nil
+	}
+	if err != nil {
+		return err
+	}
+	compiled := make(map[string]constraint.ConstraintSystem)
+	compile := func(name string, circuit frontend.Circuit) error {
+		ccs, err := s.compileCircuit(name, circuit)
+		if err != nil {
+			return err
+		}
+		compiled[name] = ccs
+		return nil
+	}
+	// with the optimization, all the circuits compiled so far must have the same domain size, checked before any key is
+	// written for them
+	check := func() error {
+		if !config.Optimization {
+			return nil
+		}
+		report, err := chainark.CheckOptimization(compiled)
+		if report != nil {
+			for _, c := range report.Circuits {
+				fmt.Fprintf(s.out, "%v: domain size %v\n", c.Name, c.DomainSize)
+			}
+		}
+		return err
+	}
+	setup := func(kind chainark.ProofKind, name string, nbLinks int) (common_utils.FingerPrintBytes, error) {
+		c, err := s.setupCircuit(kind, name, nbLinks, compiled[name], newSRS)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Set(*c)
+		err = config.writeManifest(manifest)
+		if err != nil {
+			return nil, err
+		}
+		return c.FingerPrint, nil
+	}
+
+	for _, u := range config.Units {
+		factory, err := r.unit(u.Circuit)
+		if err != nil {
+			return err
+		}
+		circuit, err := factory.Circuit(shape, u.Params)
+		if err != nil {
+			return fmt.Errorf("unit %v: %w", u.Name, err)
+		}
+		err = compile(u.Name, circuit)
+		if err != nil {
+			return err
+		}
+	}
+	err = check()
+	if err != nil {
+		return err
+	}
+	var unitFps []common_utils.FingerPrintBytes
+	for _, u := range config.Units {
+		fp, err := setup(chainark.ProofKindUnit, u.Name, u.NbLinks)
+		if err != nil {
+			return err
+		}
+		unitFps = append(unitFps, fp)
+	}
+	if *unitsOnly {
+		return nil
+	}
+	ccsUnit := compiled[config.Units[0].Name]
+
+	newRecursiveCircuit := chainark.NewMultiRecursiveCircuit[FR, G1El, G2El, GtEl]
+	newHybridCircuit := chainark.NewHybridCircuit[FR, G1El, G2El, GtEl]
+	if config.Count {
+		newRecursiveCircuit = chainark.NewMultiRecursiveCircuitWithCount[FR, G1El, G2El, GtEl]
+		newHybridCircuit = chainark.NewHybridCircuitWithCount[FR, G1El, G2El, GtEl]
+	}
+	recursiveCircuit, err := newRecursiveCircuit(
+		config.NbIDVals, config.BitsPerIDVal,
+		ccsUnit, unitFps, shape.NbSelfFps, config.Optimization)
+	if err != nil {
+		return err
+	}
+	err = compile(recursiveName, recursiveCircuit)
+	if err != nil {
+		return err
+	}
+	type paddedCircuit struct {
+		name    string
+		circuit frontend.Circuit
+		padding *chainark.Padding
+	}
+	padded := []paddedCircuit{{recursiveName, recursiveCircuit, &recursiveCircuit.Padding}}
+
+	if config.Hybrid != nil {
+		factory, err := r.component(config.Hybrid.Component)
+		if err != nil {
+			return err
+		}
+		comp, err := factory.Component(shape, config.Hybrid.Params)
+		if err != nil {
+			return fmt.Errorf("hybrid component: %w", err)
+		}
+		hybridCircuit, err := newHybridCircuit(
+			config.NbIDVals, config.BitsPerIDVal,
+			ccsUnit, unitFps, shape.NbSelfFps, comp, config.Optimization)
+		if err != nil {
+			return err
+		}
+		err = compile(hybridName, hybridCircuit)
+		if err != nil {
+			return err
+		}
+		padded = append(padded, paddedCircuit{hybridName, hybridCircuit, &hybridCircuit.Padding})
+	}
+
+	// with the optimization, the recursive and hybrid circuits are padded to the largest domain size, while the units
+	// are padded by their own params, as reported by the check
+	if config.Optimization {
+		var domainSize uint64
+		for _, ccs := range compiled {
+			domainSize = max(domainSize, chainark.DomainSize(ccs))
+		}
+		for _, c := range padded {
+			*c.padding, err = chainark.NewPadding(compiled[c.name], domainSize)
+			if err != nil {
+				return err
+			}
+			if c.padding.NbConstraints == 0 {
+				continue
+			}
+			fmt.Fprintf(s.out, "%v: padded with %v constraints\n", c.name, c.padding.NbConstraints)
+			err = compile(c.name, c.circuit)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err = check()
+	if err != nil {
+		return err
+	}
+
+	_, err = setup(chainark.ProofKindRecursive, recursiveName, 0)
+	if err != nil || config.Hybrid == nil {
+		return err
+	}
+	_, err = setup(chainark.ProofKindHybrid, hybridName, config.Hybrid.NbLinks)
+	return err
+}
+
+func runProveUnit(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("prove-unit")
+	unitName := fs.String("unit", "", "name of the unit in the config")
+	beginHex := fs.String("begin", "", "begin id in hex")
+	endHex := fs.String("end", "", "end id in hex")
+	outName := fs.String("out", "", "name of the proof and witness files to write")
+	s, err := fs.load(r, args, out, "unit", "begin", "end", "out")
+	if err != nil {
+		return err
+	}
+
+	ids, err := decodeIDs(s.config, *beginHex, *endHex)
+	if err != nil {
+		return err
+	}
+	return s.proveUnit(*unitName, ids[0], ids[1], *outName)
+}
+
+func runProveRecursive(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("prove-recursive")
+	firstName := fs.String("first", "", "name of the first proof")
+	firstCircuit := fs.String("first-circuit", "", "unit name, recursive or hybrid, the circuit of the first proof")
+	secondName := fs.String("second", "", "name of the second proof")
+	secondUnit := fs.String("second-unit", "", "unit name, the circuit of the second proof")
+	beginHex := fs.String("begin", "", "begin id in hex")
+	relayHex := fs.String("relay", "", "relay id in hex")
+	endHex := fs.String("end", "", "end id in hex")
+	outName := fs.String("out", "", "name of the proof and witness files to write")
+	s, err := fs.load(r, args, out, "first", "first-circuit", "second", "second-unit", "begin", "relay", "end", "out")
+	if err != nil {
+		return err
+	}
+
+	ids, err := decodeIDs(s.config, *beginHex, *relayHex, *endHex)
+	if err != nil {
+		return err
+	}
+	return s.proveRecursive(*firstCircuit, *firstName, *secondUnit, *secondName, ids[0], ids[1], ids[2], *outName)
+}
+
+func runProveHybrid(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("prove-hybrid")
+	firstName := fs.String("first", "", "name of the first proof")
+	firstCircuit := fs.String("first-circuit", "", "unit name, recursive or hybrid, the circuit of the first proof")
+	beginHex := fs.String("begin", "", "begin id in hex")
+	relayHex := fs.String("relay", "", "relay id in hex")
+	endHex := fs.String("end", "", "end id in hex")
+	outName := fs.String("out", "", "name of the proof and witness files to write")
+	s, err := fs.load(r, args, out, "first", "first-circuit", "begin", "relay", "end", "out")
+	if err != nil {
+		return err
+	}
+
+	ids, err := decodeIDs(s.config, *beginHex, *relayHex, *endHex)
+	if err != nil {
+		return err
+	}
+	return s.proveHybrid(*firstCircuit, *firstName, ids[0], ids[1], ids[2], *outName)
+}
+
+func runVerify(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("verify")
+	proofName := fs.String("proof", "", "name of the proof")
+	circuitName := fs.String("circuit", recursiveName, "recursive or hybrid, the circuit of the proof")
+	beginHex := fs.String("begin", "", "begin id in hex")
+	endHex := fs.String("end", "", "end id in hex")
+	s, err := fs.load(r, args, out, "proof", "begin", "end")
+	if err != nil {
+		return err
+	}
+	if *circuitName != recursiveName && *circuitName != hybridName {
+		return fmt.Errorf("%w: verify: -circuit must be %v or %v", ErrUsage, recursiveName, hybridName)
+	}
+	if err := s.config.circuitName(*circuitName); err != nil {
+		return err
+	}
+
+	ids, err := decodeIDs(s.config, *beginHex, *endHex)
+	if err != nil {
+		return err
+	}
+
+	vk, err := s.keys.VerifyingKey(*circuitName)
+	if err != nil {
+		return err
+	}
+	proof, err := operations.ReadProof(s.config.proofFile(*proofName))
+	if err != nil {
+		return err
+	}
+	pubWitness, err := operations.ReadWitness(s.config.witnessFile(*proofName))
+	if err != nil {
+		return err
+	}
+	selfFps, err := s.selfFingerPrints()
+	if err != nil {
+		return err
+	}
+
+	err = chainark.VerifyChainProof[FR, G1El, G2El, GtEl](vk, proof, pubWitness, selfFps, ids[0], ids[1], s.config.BitsPerIDVal, s.config.Count)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "proof %v verified: %x -> %x\n", *proofName, ids[0], ids[1])
+	return nil
+}
+
+func runInspect(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("inspect")
+	proofName := fs.String("proof", "", "name of the proof, or none to list the fingerprints of the circuits")
+	s, err := fs.load(r, args, out)
+	if err != nil {
+		return err
+	}
+	config := s.config
+
+	if *proofName == "" {
+		names := make([]string, 0, len(config.Units)+2)
+		for _, u := range config.Units {
+			names = append(names, u.Name)
+		}
+		names = append(names, recursiveName)
+		if config.Hybrid != nil {
+			names = append(names, hybridName)
+		}
+		for _, name := range names {
+			fp, err := s.fingerPrint(name)
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(out, "%v: not set up\n", name)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%v: %x\n", name, fp)
+		}
+		return nil
+	}
+
+	pubWitness, err := operations.ReadWitness(config.witnessFile(*proofName))
+	if err != nil {
+		return err
+	}
+	decoded, err := chainark.DecodeChainWitness[FR](pubWitness, config.idLen(), config.BitsPerIDVal, config.shape().NbSelfFps, config.Count)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "begin: %x\n", decoded.BeginID)
+	fmt.Fprintf(out, "end: %x\n", decoded.EndID)
+	for i, fp := range decoded.SelfFps {
+		fmt.Fprintf(out, "selfFps[%v]: %x\n", i, fp)
+	}
+	if decoded.HasCount {
+		fmt.Fprintf(out, "count: %v\n", decoded.Count)
+	}
+	return nil
+}
+
+func runBundle(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("bundle")
+	proofName := fs.String("proof", "", "name of the proof")
+	circuitName := fs.String("circuit", "", "the circuit of the proof: a unit name, recursive or hybrid")
+	asJSON := fs.Bool("json", false, "write the bundle as json")
+	outFile := fs.String("out", "", "bundle file, defaulting to <proof>.bundle or <proof>.bundle.json in the data dir")
+	s, err := fs.load(r, args, out, "proof", "circuit")
+	if err != nil {
+		return err
+	}
+	if err := s.config.circuitName(*circuitName); err != nil {
+		return err
+	}
+
+	set, err := s.circuitSet()
+	if err != nil {
+		return err
+	}
+	vk, err := s.keys.VerifyingKey(*circuitName)
+	if err != nil {
+		return err
+	}
+	proof, err := operations.ReadProof(s.config.proofFile(*proofName))
+	if err != nil {
+		return err
+	}
+	pubWitness, err := operations.ReadWitness(s.config.witnessFile(*proofName))
+	if err != nil {
+		return err
+	}
+
+	b, err := chainark.NewBundle[FR, G1El, G2El, GtEl](proofKind(*circuitName), vk, proof, pubWitness, set)
+	if err != nil {
+		return err
+	}
+	err = chainark.VerifyBundle[FR, G1El, G2El, GtEl](b, vk, set)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if *asJSON {
+		data, err = json.Marshal(b)
+	} else {
+		data, err = b.MarshalBinary()
+	}
+	if err != nil {
+		return err
+	}
+	if *outFile == "" {
+		*outFile = s.config.bundleFile(*proofName, *asJSON)
+	}
+	err = os.WriteFile(*outFile, data, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "saved %v: %v proof %x -> %x\n", *outFile, b.Kind, b.BeginID, b.EndID)
+	return nil
+}
+
+func runVerifyBundle(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("verify-bundle")
+	inFile := fs.String("in", "", "bundle file")
+	asJSON := fs.Bool("json", false, "read the bundle as json")
+	s, err := fs.load(r, args, out, "in")
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*inFile)
+	if err != nil {
+		return err
+	}
+	var b chainark.Bundle
+	if *asJSON {
+		err = json.Unmarshal(data, &b)
+	} else {
+		err = b.UnmarshalBinary(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	set, err := s.circuitSet()
+	if err != nil {
+		return err
+	}
+	name, err := s.circuitOf(&b)
+	if err != nil {
+		return err
+	}
+	vk, err := s.keys.VerifyingKey(name)
+	if err != nil {
+		return err
+	}
+	err = chainark.VerifyBundle[FR, G1El, G2El, GtEl](&b, vk, set)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%v proof of %v verified: %x -> %x\n", b.Kind, name, b.BeginID, b.EndID)
+	return nil
+}
+
+func runPlan(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("plan")
+	idsFile := fs.String("ids", "", "file of hex ids, one per line")
+	s, err := fs.load(r, args, out, "ids")
+	if err != nil {
+		return err
+	}
+
+	plan, err := s.plan(*idsFile)
+	if err != nil {
+		return err
+	}
+	for _, job := range plan.Jobs {
+		jobArgs := job.Args()
+		fmt.Fprintf(out, "chainark %v -config %v %v\n", jobArgs[0], *fs.config, strings.Join(jobArgs[1:], " "))
+	}
+	return nil
+}
+
+func runRun(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("run")
+	idsFile := fs.String("ids", "", "file of hex ids, one per line")
+	workers := fs.Int("workers", 1, "number of jobs proved concurrently")
+	retries := fs.Int("retries", 0, "number of retries of a failed job")
+	s, err := fs.load(r, args, &syncWriter{w: out}, "ids")
+	if err != nil {
+		return err
+	}
+
+	plan, err := s.plan(*idsFile)
+	if err != nil {
+		return err
+	}
+
+	prover, err := newProver(s)
+	if err != nil {
+		return err
+	}
+	err = orchestrator.Run(context.Background(), plan, prover, orchestrator.Options{
+		Workers: *workers,
+		Retries: *retries,
+		OnEvent: s.printEvent,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "final proof: %v\n", plan.Final)
+	return nil
+}
+
+func runFollow(r *Registry, args []string, out io.Writer) error {
+	fs := newFlagSet("follow")
+	idsFile := fs.String("ids", "", "file of hex ids, one per line, to which new ids are appended")
+	checkpoint := fs.String("checkpoint", "", "checkpoint file, defaulting to checkpoint.json in the data dir")
+	interval := fs.Duration("interval", time.Minute, "delay between polls of the ids file")
+	batch := fs.Int("batch", 0, "maximum number of links proved by a step, 0 for no limit")
+	workers := fs.Int("workers", 1, "number of jobs proved concurrently")
+	retries := fs.Int("retries", 0, "number of retries of a failed job")
+	s, err := fs.load(r, args, &syncWriter{w: out}, "ids")
+	if err != nil {
+		return err
+	}
+
+	opts, err := s.planOptions()
+	if err != nil {
+		return err
+	}
+	if *checkpoint == "" {
+		*checkpoint = filepath.Join(s.config.DataDir, "checkpoint.json")
+	}
+	store := follower.FileCheckpointStore{Path: *checkpoint}
+
+	prover, err := newProver(s)
+	if err != nil {
+		return err
+	}
+	source := checkedSource{follower.FileIDSource{Path: *idsFile}, s.config}
+	f := follower.New(source, prover, store, follower.Options{
+		Plan: opts,
+		Orchestrator: orchestrator.Options{
+			Workers: *workers,
+			Retries: *retries,
+			OnEvent: func(e orchestrator.Event) {
+				s.printEvent(e)
+				if e.Kind == orchestrator.JobSucceeded && e.Job.Kind != planner.KindUnit {
+					fmt.Fprintf(s.out, "checkpoint: %v up to id %v\n", e.Job.Output, e.Job.End)
+				}
+			},
+		},
+		MaxBatch:     *batch,
+		PollInterval: *interval,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = f.Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// checkedSource checks the length of the ids against the config.
+type checkedSource struct {
+	follower.IDSource
+	config *Config
+}
+
+func (c checkedSource) IDs(ctx context.Context, from, max int) ([]chainark.LinkageIDBytes, error) {
+	ids, err := c.IDSource.IDs(ctx, from, max)
+	if err != nil {
+		return nil, err
+	}
+	_, err = decodeIDs(c.config, hexIDs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *session) printEvent(e orchestrator.Event) {
+	if e.Err != nil {
+		fmt.Fprintf(s.out, "[%v/%v] %v %v: %v\n", e.Done, e.Total, e.Job.Output, e.Kind, e.Err)
+	} else {
+		fmt.Fprintf(s.out, "[%v/%v] %v %v\n", e.Done, e.Total, e.Job.Output, e.Kind)
+	}
+}
+
+// plan reads the ids in idsFile and plans their proving jobs with the units and the hybrid component of the config.
+func (s *session) plan(idsFile string) (*planner.Plan, error) {
+	opts, err := s.planOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(idsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ids, err := planner.ReadIDs(f)
+	if err != nil {
+		return nil, err
+	}
+	_, err = decodeIDs(s.config, hexIDs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return planner.New(ids, opts)
+}
+
+func (s *session) planOptions() (planner.Options, error) {
+	var opts planner.Options
+	for _, u := range s.config.Units {
+		if u.NbLinks <= 0 {
+			return opts, fmt.Errorf("%w: nbLinks of unit %v is required to plan", ErrBadConfig, u.Name)
+		}
+		opts.Units = append(opts.Units, planner.Variant{Name: u.Name, NbLinks: u.NbLinks})
+	}
+	if s.config.Hybrid != nil {
+		if s.config.Hybrid.NbLinks <= 0 {
+			return opts, fmt.Errorf("%w: nbLinks of the hybrid component is required to plan", ErrBadConfig)
+		}
+		opts.Hybrid = &planner.Variant{Name: hybridName, NbLinks: s.config.Hybrid.NbLinks}
+	}
+	return opts, nil
+}
+
+func hexIDs(ids []chainark.LinkageIDBytes) []string {
+	ret := make([]string, len(ids))
+	for i := 0; i < len(ids); i++ {
+		ret[i] = hex.EncodeToString(ids[i])
+	}
+	return ret
+}
+
+// decodeIDs decodes hex linkage ids, checking their length against the config.
+func decodeIDs(config *Config, hexIDs ...string) ([]chainark.LinkageIDBytes, error) {
+	expected := config.idLen()
+	ret := make([]chainark.LinkageIDBytes, len(hexIDs))
+	for i, s := range hexIDs {
+		id, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: id %q: %v", ErrUsage, s, err)
+		}
+		if len(id) != expected {
+			return nil, fmt.Errorf("%w: id %q has %v bytes, expecting %v", ErrUsage, s, len(id), expected)
+		}
+		ret[i] = id
+	}
+	return ret, nil
+}
+
+// compileCircuit compiles circuit, named name.
+func (s *session) compileCircuit(name string, circuit frontend.Circuit) (constraint.ConstraintSystem, error) {
+	fmt.Fprintf(s.out, "compiling %v ...\n", name)
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+	fmt.Fprintf(s.out, "%v: %v constraints\n", name, ccs.GetNbConstraints())
+	return ccs, nil
+}
+
+// srsLoader returns the SRS and its Lagrange form to set up ccs with.
+type srsLoader func(ccs constraint.ConstraintSystem) (kzg.SRS, kzg.SRS, error)
+
+// srsLoader loads the SRS from the files of dir, or of the srsDir of the config, unless unsafe.
+func (s *session) srsLoader(dir string, unsafe bool) (srsLoader, error) {
+	if unsafe {
+		fmt.Fprintln(s.out, "WARNING: the SRS of -unsafe-srs has a known toxic waste, so that anyone could forge proofs: use it for tests only")
+		return func(ccs constraint.ConstraintSystem) (kzg.SRS, kzg.SRS, error) {
+			return unsafekzg.NewSRS(ccs, unsafekzg.WithFSCache())
+		}, nil
+	}
+
+	if dir == "" {
+		dir = s.config.SRSDir
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("%w: setup: -srs-dir or srsDir in the config is required, or -unsafe-srs for tests", ErrUsage)
+	}
+	return func(ccs constraint.ConstraintSystem) (kzg.SRS, kzg.SRS, error) {
+		srs, srsLagrange, err := operations.ReadSrs(ccs.GetNbConstraints()+ccs.GetNbPublicVariables(), dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		return *srs, *srsLagrange, nil
+	}, nil
+}
+
+// setupCircuit sets up the compiled circuit with the SRS of newSRS, then writes its keys as name.
+func (s *session) setupCircuit(kind chainark.ProofKind, name string, nbLinks int, ccs constraint.ConstraintSystem,
+	newSRS srsLoader) (*chainark.ManifestCircuit, error) {
+	srs, srsLagrange, err := newSRS(ccs)
+	if err != nil {
+		return nil, err
+	}
+	pk, vk, err := native_plonk.Setup(ccs, srs, srsLagrange)
+	if err != nil {
+		return nil, err
+	}
+
+	err = operations.WriteCcs(ccs, s.config.ccsFile(name))
+	if err != nil {
+		return nil, err
+	}
+	err = operations.WritePk(pk, s.config.pkFile(name))
+	if err != nil {
+		return nil, err
+	}
+	err = operations.WriteVk(vk, s.config.vkFile(name))
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := chainark.NewManifestCircuit[FR, G1El, G2El, GtEl](kind, name, nbLinks, ccs, vk)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(s.out, "%v: saved ccs, pk, vk, fingerprint %x\n", name, c.FingerPrint)
+	return c, nil
+}
+
+// syncWriter serializes the writes of concurrent jobs.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
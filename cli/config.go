@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lightec-xyz/chainark"
+)
+
+const (
+	recursiveName = "recursive"
+	hybridName    = "hybrid"
+)
+
+// Config is the JSON file describing the circuits of an application, by the names they are registered with.
+type Config struct {
+	DataDir      string        `json:"dataDir"`          // relative to the config file
+	SRSDir       string        `json:"srsDir,omitempty"` // relative to the config file, see setup
+	NbIDVals     int           `json:"nbIdVals"`
+	BitsPerIDVal int           `json:"bitsPerIdVal"`
+	NbIDBytes    int           `json:"nbIdBytes,omitempty"` // for ids with a partial top value, see Shape
+	Optimization bool          `json:"optimization,omitempty"`
+	Count        bool          `json:"count,omitempty"` // the units expose a ChainCount
+	Units        []UnitConfig  `json:"units"`           // the first unit is used as the shape of all inner proofs
+	Hybrid       *HybridConfig `json:"hybrid,omitempty"`
+	ProofStore   string        `json:"proofStore,omitempty"` // relative to the data dir, see Prover
+	NoManifest   bool          `json:"noManifest,omitempty"` // load keys set up without a manifest, see setup
+}
+
+type UnitConfig struct {
+	Name    string          `json:"name"`              // the name of the keys and the proofs of this unit
+	Circuit string          `json:"circuit"`           // the name the circuit is registered with
+	NbLinks int             `json:"nbLinks,omitempty"` // the number of links proved, required by plan
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type HybridConfig struct {
+	Component string          `json:"component"`         // the name the component is registered with
+	NbLinks   int             `json:"nbLinks,omitempty"` // the number of links verified, required by plan
+	Params    json.RawMessage `json:"params,omitempty"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadConfig, err)
+	}
+	if !filepath.IsAbs(config.DataDir) {
+		config.DataDir = filepath.Join(filepath.Dir(path), config.DataDir)
+	}
+	if config.SRSDir != "" && !filepath.IsAbs(config.SRSDir) {
+		config.SRSDir = filepath.Join(filepath.Dir(path), config.SRSDir)
+	}
+	return &config, nil
+}
+
+// Validate checks the config against the circuits registered in r.
+func (c *Config) Validate(r *Registry) error {
+	if c.NbIDVals <= 0 || c.BitsPerIDVal <= 0 {
+		return fmt.Errorf("%w: nbIdVals and bitsPerIdVal must be positive", ErrBadConfig)
+	}
+	if c.NbIDBytes == 0 && c.NbIDVals*c.BitsPerIDVal%8 != 0 {
+		return fmt.Errorf("%w: ids of %vx%v bits are not on whole bytes, set nbIdBytes", ErrBadConfig,
+			c.NbIDVals, c.BitsPerIDVal)
+	}
+	if c.NbIDBytes != 0 && (c.NbIDBytes*8+c.BitsPerIDVal-1)/c.BitsPerIDVal != c.NbIDVals {
+		return fmt.Errorf("%w: ids of %v bytes do not take %v values of %v bits", ErrBadConfig,
+			c.NbIDBytes, c.NbIDVals, c.BitsPerIDVal)
+	}
+	if len(c.Units) == 0 {
+		return fmt.Errorf("%w: no unit circuit", ErrBadConfig)
+	}
+
+	names := make(map[string]bool)
+	for _, u := range c.Units {
+		if u.Name == "" || u.Name == recursiveName || u.Name == hybridName {
+			return fmt.Errorf("%w: invalid unit name %q", ErrBadConfig, u.Name)
+		}
+		if names[u.Name] {
+			return fmt.Errorf("%w: duplicate unit name %q", ErrBadConfig, u.Name)
+		}
+		names[u.Name] = true
+
+		if _, err := r.unit(u.Circuit); err != nil {
+			return err
+		}
+	}
+
+	if c.Hybrid != nil {
+		if _, err := r.component(c.Hybrid.Component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) shape() Shape {
+	nbSelfFps := 1
+	if c.Hybrid != nil {
+		nbSelfFps = 2
+	}
+	return Shape{
+		NbIDVals:     c.NbIDVals,
+		BitsPerIDVal: c.BitsPerIDVal,
+		NbIDBytes:    c.idLen(),
+		NbSelfFps:    nbSelfFps,
+		Count:        c.Count,
+	}
+}
+
+// idLen returns the byte length of the ids.
+func (c *Config) idLen() int {
+	if c.NbIDBytes != 0 {
+		return c.NbIDBytes
+	}
+	return c.NbIDVals * c.BitsPerIDVal / 8
+}
+
+func (c *Config) unit(name string) (*UnitConfig, error) {
+	for i := 0; i < len(c.Units); i++ {
+		if c.Units[i].Name == name {
+			return &c.Units[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no unit named %q", ErrBadConfig, name)
+}
+
+// circuitName checks that name is a unit name, recursive or hybrid (when configured).
+func (c *Config) circuitName(name string) error {
+	if name == recursiveName || (name == hybridName && c.Hybrid != nil) {
+		return nil
+	}
+	_, err := c.unit(name)
+	return err
+}
+
+func (c *Config) manifestFile() string {
+	return filepath.Join(c.DataDir, "manifest.json")
+}
+
+// readManifest reads the manifest written by setup, checking its shape against the config.
+func (c *Config) readManifest() (*chainark.Manifest, error) {
+	data, err := os.ReadFile(c.manifestFile())
+	if err != nil {
+		return nil, err
+	}
+	var m chainark.Manifest
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", c.manifestFile(), err)
+	}
+	if m.NbIDVals != c.NbIDVals || m.BitsPerIDVal != c.BitsPerIDVal {
+		return nil, fmt.Errorf("%w: manifest of %vx%v bits ids, config of %vx%v bits", chainark.ErrManifestMismatch,
+			m.NbIDVals, m.BitsPerIDVal, c.NbIDVals, c.BitsPerIDVal)
+	}
+	if m.NbIDBytes != c.NbIDBytes {
+		return nil, fmt.Errorf("%w: manifest of %v bytes ids, config of %v bytes", chainark.ErrManifestMismatch,
+			m.NbIDBytes, c.NbIDBytes)
+	}
+	if m.Count != c.Count {
+		return nil, fmt.Errorf("%w: manifest count %v, config count %v", chainark.ErrManifestMismatch, m.Count, c.Count)
+	}
+	return &m, nil
+}
+
+func (c *Config) writeManifest(m *chainark.Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.manifestFile(), data, 0644)
+}
+
+func (c *Config) ccsFile(name string) string {
+	return filepath.Join(c.DataDir, name+".ccs")
+}
+
+func (c *Config) pkFile(name string) string {
+	return filepath.Join(c.DataDir, name+".pk")
+}
+
+func (c *Config) vkFile(name string) string {
+	return filepath.Join(c.DataDir, name+".vk")
+}
+
+func (c *Config) bundleFile(name string, asJSON bool) string {
+	if asJSON {
+		return filepath.Join(c.DataDir, name+".bundle.json")
+	}
+	return filepath.Join(c.DataDir, name+".bundle")
+}
+
+func (c *Config) proofFile(name string) string {
+	return filepath.Join(c.DataDir, name+".proof")
+}
+
+func (c *Config) witnessFile(name string) string {
+	return filepath.Join(c.DataDir, name+".wtns")
+}
+
+func (c *Config) proofStoreDir() string {
+	if filepath.IsAbs(c.ProofStore) {
+		return c.ProofStore
+	}
+	return filepath.Join(c.DataDir, c.ProofStore)
+}
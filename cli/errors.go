@@ -0,0 +1,12 @@
+package cli
+
+import "errors"
+
+// sentinel errors returned by the command line tool, to be matched with errors.Is
+var (
+	ErrUsage             = errors.New("chainark: bad usage")
+	ErrBadConfig         = errors.New("chainark: bad config")
+	ErrNotRegistered     = errors.New("chainark: circuit not registered")
+	ErrDuplicateName     = errors.New("chainark: name already registered")
+	ErrIncompleteFactory = errors.New("chainark: factory misses a constructor")
+)
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/lightec-xyz/chainark"
+)
+
+// the command line tool proves and verifies BN254 in BN254, as the example does
+type (
+	FR   = sw_bn254.ScalarField
+	G1El = sw_bn254.G1Affine
+	G2El = sw_bn254.G2Affine
+	GtEl = sw_bn254.GTEl
+)
+
+// Shape describes the linkage ids and the self fingerprints shared by all the circuits of an application. Unit
+// circuits must expose NbSelfFps place holder fingerprints, that is 2 with a hybrid component configured, 1 otherwise,
+// and a ChainCount iff Count, see chainark.NewMultiUnitCircuitWithCount. The ids take NbIDBytes bytes: when these are
+// not NbIDVals whole values of BitsPerIDVal bits, the top value is partial, see chainark.LinkageIDFromBytes, and unit
+// circuits create their placeholder ids with chainark.PlaceholderLinkageIDOfBits.
+type Shape struct {
+	NbIDVals     int
+	BitsPerIDVal int
+	NbIDBytes    int
+	NbSelfFps    int
+	Count        bool
+}
+
+// UnitFactory builds the circuit and the assignments of an application unit circuit, from the params of its config.
+type UnitFactory struct {
+	Circuit    func(shape Shape, params json.RawMessage) (frontend.Circuit, error)
+	Assignment func(shape Shape, params json.RawMessage, beginID, endID chainark.LinkageIDBytes) (frontend.Circuit, error)
+}
+
+// ComponentFactory builds the UnitCore component verified in circuit by the hybrid circuit. With counting enabled,
+// the component must implement chainark.CountedUnitCore.
+type ComponentFactory struct {
+	Component  func(shape Shape, params json.RawMessage) (chainark.UnitCore[FR, G1El, G2El, GtEl], error)
+	Assignment func(shape Shape, params json.RawMessage, beginID, endID chainark.LinkageIDBytes) (chainark.UnitCore[FR, G1El, G2El, GtEl], error)
+}
+
+// Registry maps the circuit names found in the config to the application code building them.
+type Registry struct {
+	units      map[string]UnitFactory
+	components map[string]ComponentFactory
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		units:      make(map[string]UnitFactory),
+		components: make(map[string]ComponentFactory),
+	}
+}
+
+func (r *Registry) RegisterUnit(name string, f UnitFactory) error {
+	if f.Circuit == nil || f.Assignment == nil {
+		return fmt.Errorf("%w: unit %v", ErrIncompleteFactory, name)
+	}
+	if _, ok := r.units[name]; ok {
+		return fmt.Errorf("%w: unit %v", ErrDuplicateName, name)
+	}
+	r.units[name] = f
+	return nil
+}
+
+func (r *Registry) RegisterComponent(name string, f ComponentFactory) error {
+	if f.Component == nil || f.Assignment == nil {
+		return fmt.Errorf("%w: component %v", ErrIncompleteFactory, name)
+	}
+	if _, ok := r.components[name]; ok {
+		return fmt.Errorf("%w: component %v", ErrDuplicateName, name)
+	}
+	r.components[name] = f
+	return nil
+}
+
+func (r *Registry) unit(name string) (UnitFactory, error) {
+	f, ok := r.units[name]
+	if !ok {
+		return UnitFactory{}, fmt.Errorf("%w: unit circuit %v, registered: %v", ErrNotRegistered, name, sortedKeys(r.units))
+	}
+	return f, nil
+}
+
+func (r *Registry) component(name string) (ComponentFactory, error) {
+	f, ok := r.components[name]
+	if !ok {
+		return ComponentFactory{}, fmt.Errorf("%w: component %v, registered: %v", ErrNotRegistered, name, sortedKeys(r.components))
+	}
+	return f, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,469 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/orchestrator"
+	"github.com/lightec-xyz/chainark/planner"
+	"github.com/lightec-xyz/chainark/proofstore"
+	"github.com/lightec-xyz/common/operations"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// session holds what the subcommands share: the config, the registered circuits and the keys loaded so far.
+type session struct {
+	r      *Registry
+	config *Config
+	keys   *orchestrator.KeyCache
+	out    io.Writer
+}
+
+func newSession(r *Registry, config *Config, out io.Writer) *session {
+	return &session{
+		r:      r,
+		config: config,
+		keys:   orchestrator.NewKeyCache(fileLoader{config}),
+		out:    out,
+	}
+}
+
+// fileLoader loads the keys written by setup, refusing the keys diverging from the manifest or without one.
+type fileLoader struct {
+	config *Config
+}
+
+func (l fileLoader) LoadKeys(name string) (*orchestrator.Keys, error) {
+	ccs, err := operations.ReadCcs(l.config.ccsFile(name))
+	if err != nil {
+		return nil, err
+	}
+	pk, err := operations.ReadPk(l.config.pkFile(name))
+	if err != nil {
+		return nil, err
+	}
+	vk, err := l.LoadVerifyingKey(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := l.manifest()
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		err = m.CheckCcs(name, ccs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &orchestrator.Keys{CCS: ccs, PK: pk, VK: vk}, nil
+}
+
+func (l fileLoader) LoadVerifyingKey(name string) (native_plonk.VerifyingKey, error) {
+	vk, err := operations.ReadVk(l.config.vkFile(name))
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := l.manifest()
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		err = m.CheckVk(name, vk)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vk, nil
+}
+
+// manifest returns the manifest written by setup along with the keys. A missing manifest is an error, unless the
+// config sets NoManifest, for keys set up by other means: manifest then returns nil.
+func (l fileLoader) manifest() (*chainark.Manifest, error) {
+	m, err := l.config.readManifest()
+	if errors.Is(err, os.ErrNotExist) {
+		if l.config.NoManifest {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: no %v, run setup or set noManifest", chainark.ErrManifestMismatch,
+			l.config.manifestFile())
+	}
+	return m, err
+}
+
+// Prover proves the jobs of a plan built from the config, as the prove subcommands do. When the config has a
+// ProofStore, each proof is also put in the FileStore of that directory, along with the range and the ids of its job.
+type Prover struct {
+	s     *session
+	store proofstore.ProofStore // nil without a ProofStore in the config
+}
+
+// NewProver returns a Prover reading and writing the files in the data dir of config. The keys are loaded once and
+// shared by all the jobs. Progress messages are written to out, which must be safe for concurrent use when the
+// Prover is used by several workers.
+func NewProver(r *Registry, config *Config, out io.Writer) (*Prover, error) {
+	err := config.Validate(r)
+	if err != nil {
+		return nil, err
+	}
+	return newProver(newSession(r, config, out))
+}
+
+func newProver(s *session) (*Prover, error) {
+	p := &Prover{s: s}
+	if s.config.ProofStore != "" {
+		store, err := proofstore.OpenFileStore(s.config.proofStoreDir())
+		if err != nil {
+			return nil, err
+		}
+		p.store = store
+	}
+	return p, nil
+}
+
+func (p *Prover) Prove(ctx context.Context, job *planner.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	switch job.Kind {
+	case planner.KindUnit:
+		err = p.s.proveUnit(job.Circuit, job.BeginID, job.EndID, job.Output)
+	case planner.KindRecursive:
+		err = p.s.proveRecursive(job.Inputs[0].Circuit, job.Inputs[0].Artifact, job.Inputs[1].Circuit, job.Inputs[1].Artifact,
+			job.BeginID, job.RelayID, job.EndID, job.Output)
+	case planner.KindHybrid:
+		err = p.s.proveHybrid(job.Inputs[0].Circuit, job.Inputs[0].Artifact, job.BeginID, job.RelayID, job.EndID, job.Output)
+	default:
+		return fmt.Errorf("unknown job kind %v", job.Kind)
+	}
+	if err != nil || p.store == nil {
+		return err
+	}
+	return p.s.storeProof(p.store, job)
+}
+
+// storeProof puts in store the proof of job, read back from the files written by prove.
+func (s *session) storeProof(store proofstore.ProofStore, job *planner.Job) error {
+	proof, err := operations.ReadProof(s.config.proofFile(job.Output))
+	if err != nil {
+		return err
+	}
+	pubWitness, err := operations.ReadWitness(s.config.witnessFile(job.Output))
+	if err != nil {
+		return err
+	}
+	fp, err := s.fingerPrint(job.Circuit)
+	if err != nil {
+		return err
+	}
+
+	record, err := proofstore.NewRecord(proofstore.Meta{
+		Kind:        job.Kind,
+		Circuit:     job.Circuit,
+		FingerPrint: fp,
+		BeginID:     job.BeginID,
+		EndID:       job.EndID,
+		Begin:       job.Begin,
+		End:         job.End,
+	}, proof, pubWitness)
+	if err != nil {
+		return err
+	}
+	h, err := store.Put(record)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "stored %v as %v\n", job.Output, h)
+	return nil
+}
+
+func (s *session) proveUnit(unitName string, beginID, endID chainark.LinkageIDBytes, outName string) error {
+	u, err := s.config.unit(unitName)
+	if err != nil {
+		return err
+	}
+	factory, err := s.r.unit(u.Circuit)
+	if err != nil {
+		return err
+	}
+	assignment, err := factory.Assignment(s.config.shape(), u.Params, beginID, endID)
+	if err != nil {
+		return fmt.Errorf("unit %v: %w", u.Name, err)
+	}
+	return s.prove(u.Name, assignment, outName)
+}
+
+func (s *session) proveRecursive(firstCircuit, firstName, secondUnit, secondName string,
+	beginID, relayID, endID chainark.LinkageIDBytes, outName string) error {
+	if _, err := s.config.unit(secondUnit); err != nil {
+		return err
+	}
+
+	first, err := s.loadInner(firstCircuit, firstName)
+	if err != nil {
+		return err
+	}
+	second, err := s.loadInner(secondUnit, secondName)
+	if err != nil {
+		return err
+	}
+	selfFps, err := s.selfFingerPrints()
+	if err != nil {
+		return err
+	}
+
+	bits := s.config.BitsPerIDVal
+	fps := chainark.FingerPrintsOf[FR](selfFps)
+	begin := chainark.LinkageIDFromBytes(beginID, bits)
+	relay := chainark.LinkageIDFromBytes(relayID, bits)
+	end := chainark.LinkageIDFromBytes(endID, bits)
+	if s.config.Count {
+		assignment := chainark.NewMultiRecursiveAssignmentWithCount[FR, G1El, G2El, GtEl](
+			first.vk, second.vk, first.proof, second.proof, first.witness, second.witness, fps, begin, relay, end,
+			first.decoded.Count+second.decoded.Count,
+		)
+		return s.prove(recursiveName, assignment, outName)
+	}
+	assignment := chainark.NewMultiRecursiveAssignment[FR, G1El, G2El, GtEl](
+		first.vk, second.vk, first.proof, second.proof, first.witness, second.witness, fps, begin, relay, end,
+	)
+	return s.prove(recursiveName, assignment, outName)
+}
+
+func (s *session) proveHybrid(firstCircuit, firstName string, beginID, relayID, endID chainark.LinkageIDBytes, outName string) error {
+	if s.config.Hybrid == nil {
+		return fmt.Errorf("%w: no hybrid component configured", ErrBadConfig)
+	}
+
+	first, err := s.loadInner(firstCircuit, firstName)
+	if err != nil {
+		return err
+	}
+	selfFps, err := s.selfFingerPrints()
+	if err != nil {
+		return err
+	}
+
+	factory, err := s.r.component(s.config.Hybrid.Component)
+	if err != nil {
+		return err
+	}
+	comp, err := factory.Assignment(s.config.shape(), s.config.Hybrid.Params, relayID, endID)
+	if err != nil {
+		return fmt.Errorf("hybrid component: %w", err)
+	}
+
+	bits := s.config.BitsPerIDVal
+	fps := chainark.FingerPrintsOf[FR](selfFps)
+	begin := chainark.LinkageIDFromBytes(beginID, bits)
+	relay := chainark.LinkageIDFromBytes(relayID, bits)
+	end := chainark.LinkageIDFromBytes(endID, bits)
+	if s.config.Count {
+		// the number of links is a constant of the component circuit, not of its assignment
+		circuitComp, err := factory.Component(s.config.shape(), s.config.Hybrid.Params)
+		if err != nil {
+			return fmt.Errorf("hybrid component: %w", err)
+		}
+		counted, ok := circuitComp.(chainark.CountedUnitCore)
+		if !ok {
+			return fmt.Errorf("%w: hybrid component does not implement CountedUnitCore", ErrBadConfig)
+		}
+		assignment := chainark.NewHybridAssignmentWithCount[FR, G1El, G2El, GtEl](
+			first.vk, first.proof, first.witness, fps, begin, relay, end, comp,
+			first.decoded.Count+uint64(counted.GetNbLinks()),
+		)
+		return s.prove(hybridName, assignment, outName)
+	}
+	assignment := chainark.NewHybridAssignment[FR, G1El, G2El, GtEl](
+		first.vk, first.proof, first.witness, fps, begin, relay, end, comp,
+	)
+	return s.prove(hybridName, assignment, outName)
+}
+
+// prove proves assignment with the keys of circuit name, verifies the proof, then writes the proof and its public
+// witness as outName.
+func (s *session) prove(name string, assignment frontend.Circuit, outName string) error {
+	k, err := s.keys.Keys(name)
+	if err != nil {
+		return err
+	}
+
+	w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return err
+	}
+	pubWitness, err := w.Public()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(s.out, "proving %v with %v ...\n", outName, name)
+	proof, err := native_plonk.Prove(k.CCS, k.PK, w,
+		plonk.GetNativeProverOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField()))
+	if err != nil {
+		return err
+	}
+	err = native_plonk.Verify(proof, k.VK, pubWitness,
+		plonk.GetNativeVerifierOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField()))
+	if err != nil {
+		return fmt.Errorf("%w: %v", chainark.ErrInvalidProof, err)
+	}
+
+	err = operations.WriteProof(proof, s.config.proofFile(outName))
+	if err != nil {
+		return err
+	}
+	err = operations.WriteWitness(pubWitness, s.config.witnessFile(outName))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "saved %v, %v\n", s.config.proofFile(outName), s.config.witnessFile(outName))
+	return nil
+}
+
+// innerProof is a proof to be verified in a recursive or hybrid circuit.
+type innerProof struct {
+	vk      plonk.VerifyingKey[FR, G1El, G2El]
+	proof   plonk.Proof[FR, G1El, G2El]
+	witness plonk.Witness[FR]
+	decoded *chainark.ChainWitness
+}
+
+// loadInner loads proof name generated by circuit, which is a unit name, recursive or hybrid.
+func (s *session) loadInner(circuit, name string) (*innerProof, error) {
+	err := s.config.circuitName(circuit)
+	if err != nil {
+		return nil, err
+	}
+
+	_vk, err := s.keys.VerifyingKey(circuit)
+	if err != nil {
+		return nil, err
+	}
+	_proof, err := operations.ReadProof(s.config.proofFile(name))
+	if err != nil {
+		return nil, err
+	}
+	_witness, err := operations.ReadWitness(s.config.witnessFile(name))
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := chainark.DecodeChainWitness[FR](_witness, s.config.idLen(), s.config.BitsPerIDVal, s.config.shape().NbSelfFps, s.config.Count)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+
+	vk, err := plonk.ValueOfVerifyingKey[FR, G1El, G2El](_vk)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := plonk.ValueOfProof[FR, G1El, G2El](_proof)
+	if err != nil {
+		return nil, err
+	}
+	witness, err := plonk.ValueOfWitness[FR](_witness)
+	if err != nil {
+		return nil, err
+	}
+
+	return &innerProof{
+		vk:      vk,
+		proof:   proof,
+		witness: witness,
+		decoded: decoded,
+	}, nil
+}
+
+func (s *session) fingerPrint(name string) (common_utils.FingerPrintBytes, error) {
+	vk, err := s.keys.VerifyingKey(name)
+	if err != nil {
+		return nil, err
+	}
+	return chainark.UnsafeFingerPrintFromVk[FR, G1El, G2El, GtEl](vk)
+}
+
+// selfFingerPrints returns {recursiveFp} or {recursiveFp, hybridFp} when a hybrid component is configured.
+func (s *session) selfFingerPrints() ([]common_utils.FingerPrintBytes, error) {
+	names := []string{recursiveName}
+	if s.config.Hybrid != nil {
+		names = append(names, hybridName)
+	}
+
+	ret := make([]common_utils.FingerPrintBytes, len(names))
+	for i, name := range names {
+		fp, err := s.fingerPrint(name)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = fp
+	}
+	return ret, nil
+}
+
+// circuitSet returns the fingerprints of all the circuits of the config.
+func (s *session) circuitSet() (*chainark.CircuitSet, error) {
+	selfFps, err := s.selfFingerPrints()
+	if err != nil {
+		return nil, err
+	}
+	set := &chainark.CircuitSet{
+		NbIDVals:     s.config.NbIDVals,
+		BitsPerIDVal: s.config.BitsPerIDVal,
+		NbIDBytes:    s.config.NbIDBytes,
+		SelfFps:      selfFps,
+		Count:        s.config.Count,
+	}
+	for _, u := range s.config.Units {
+		fp, err := s.fingerPrint(u.Name)
+		if err != nil {
+			return nil, err
+		}
+		set.UnitFps = append(set.UnitFps, fp)
+	}
+	return set, nil
+}
+
+// circuitOf returns the name of the circuit of b, looking up the units by fingerprint.
+func (s *session) circuitOf(b *chainark.Bundle) (string, error) {
+	switch b.Kind {
+	case chainark.ProofKindRecursive:
+		return recursiveName, nil
+	case chainark.ProofKindHybrid:
+		return hybridName, s.config.circuitName(hybridName)
+	}
+	for _, u := range s.config.Units {
+		fp, err := s.fingerPrint(u.Name)
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(fp, b.VkFp) {
+			return u.Name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unit fingerprint %x", chainark.ErrUnknownVkFp, b.VkFp)
+}
+
+func proofKind(circuit string) chainark.ProofKind {
+	switch circuit {
+	case recursiveName:
+		return chainark.ProofKindRecursive
+	case hybridName:
+		return chainark.ProofKindHybrid
+	default:
+		return chainark.ProofKindUnit
+	}
+}
@@ -0,0 +1,162 @@
+package chainark
+
+import (
+	"fmt"
+	"math/big"
+
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * A recursive circuit could not embed its own fingerprint, so the SelfFps are public inputs of the recursive and
+ * hybrid proofs, which every verifier must check. The Verifier wrapper checks them against constants; the
+ * CommittedVerifier instead publishes SelfFpsCommitment, the MiMC hash of the SelfFps found in the inner witness, and
+ * checks that the inner vk is one of them. Its public witness is then one value whatever the number of SelfFps, to be
+ * compared with SelfFpsCommitment computed natively, and its keys do not depend on the fingerprints.
+ */
+
+type CommittedVerifier[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	VKey    plonk.VerifyingKey[FR, G1El, G2El]
+	Proof   plonk.Proof[FR, G1El, G2El]
+	Witness plonk.Witness[FR]
+
+	SelfFps           []common_utils.FingerPrint[FR] // as found in Witness
+	SelfFpsCommitment frontend.Variable              `gnark:",public"`
+
+	// circuit constants
+	NbIdVars  int
+	NbFpVars  int
+	NbSelfFps int
+	MinCount  uint64 // if not zero, the inner proof must have a ChainCount of at least MinCount
+}
+
+func (c *CommittedVerifier[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	if c.NbSelfFps != len(c.SelfFps) {
+		return fmt.Errorf("%w: %v vs %v self fingerprints", ErrBadSelfFpCount, c.NbSelfFps, len(c.SelfFps))
+	}
+
+	vkeyFp, err := common_utils.InCircuitFingerPrint[FR, G1El, G2El](api, &c.VKey)
+	if err != nil {
+		return err
+	}
+	common_utils.AssertFpInFpSet[FR](api, vkeyFp, c.SelfFps)
+
+	initialOffset := c.NbIdVars * 2
+	setTest := TestRecursiveFps[FR](api, c.Witness, c.SelfFps, initialOffset, c.NbFpVars, c.NbSelfFps)
+	api.AssertIsEqual(1, setTest)
+
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(c.SelfFps); i++ {
+		h.Write(c.SelfFps[i].Val)
+	}
+	api.AssertIsEqual(h.Sum(), c.SelfFpsCommitment)
+
+	if c.MinCount > 0 {
+		count := RetrieveCount[FR](api, c.Witness, c.NbIdVars, c.NbFpVars, c.NbSelfFps)
+		rcheck := rangecheck.New(api)
+		rcheck.Check(api.Sub(count, c.MinCount), nbCountBits)
+	}
+
+	verifier, err := plonk.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return err
+	}
+	return verifier.AssertProof(c.VKey, c.Proof, c.Witness, plonk.WithCompleteArithmetic())
+}
+
+func NewCommittedVerifierCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	ccs constraint.ConstraintSystem,
+	nbIdVars, nbFpVars, nbSelfFps int,
+	minCount ...uint64,
+) (*CommittedVerifier[FR, G1El, G2El, GtEl], error) {
+	if nbSelfFps <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
+	}
+	minCnt := uint64(0)
+	if len(minCount) != 0 {
+		minCnt = minCount[0]
+	}
+	return &CommittedVerifier[FR, G1El, G2El, GtEl]{
+		VKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccs),
+		Proof:   plonk.PlaceholderProof[FR, G1El, G2El](ccs),
+		Witness: plonk.PlaceholderWitness[FR](ccs),
+		SelfFps: make([]common_utils.FingerPrint[FR], nbSelfFps),
+
+		NbIdVars:  nbIdVars,
+		NbFpVars:  nbFpVars,
+		NbSelfFps: nbSelfFps,
+		MinCount:  minCnt,
+	}, nil
+}
+
+// NewCommittedVerifierAssignment assigns the inner proof, selfFps being the SelfFps found in its witness.
+func NewCommittedVerifierAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vkey native_plonk.VerifyingKey,
+	proof native_plonk.Proof,
+	witness witness.Witness,
+	selfFps []common_utils.FingerPrintBytes,
+) (*CommittedVerifier[FR, G1El, G2El, GtEl], error) {
+	vk, err := plonk.ValueOfVerifyingKey[FR, G1El, G2El](vkey)
+	if err != nil {
+		return nil, err
+	}
+	pf, err := plonk.ValueOfProof[FR, G1El, G2El](proof)
+	if err != nil {
+		return nil, err
+	}
+	wt, err := plonk.ValueOfWitness[FR](witness)
+	if err != nil {
+		return nil, err
+	}
+	commitment, err := SelfFpsCommitment[FR, G1El, G2El, GtEl](selfFps)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommittedVerifier[FR, G1El, G2El, GtEl]{
+		VKey:              vk,
+		Proof:             pf,
+		Witness:           wt,
+		SelfFps:           FingerPrintsOf[FR](selfFps),
+		SelfFpsCommitment: new(big.Int).SetBytes(commitment),
+	}, nil
+}
+
+// SelfFpsCommitment returns natively the SelfFpsCommitment published by a CommittedVerifier verifying proofs of the
+// recursive circuits whose fingerprints are selfFps, in their order.
+func SelfFpsCommitment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	selfFps []common_utils.FingerPrintBytes) ([]byte, error) {
+	if len(selfFps) == 0 {
+		return nil, fmt.Errorf("%w: no self fingerprint", ErrBadSelfFpCount)
+	}
+
+	// the hash of the circuit verifying the proofs over FR, as for the fingerprints
+	h, err := outerHash[FR]()
+	if err != nil {
+		return nil, err
+	}
+	hasher := h.New()
+	for i := 0; i < len(selfFps); i++ {
+		fp := new(big.Int).SetBytes(selfFps[i])
+		if (fp.BitLen()+7)/8 > hasher.BlockSize() {
+			return nil, fmt.Errorf("fingerprint %v longer than %v bytes", i, hasher.BlockSize())
+		}
+		_, err := hasher.Write(fp.FillBytes(make([]byte, hasher.BlockSize())))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hasher.Sum(nil), nil
+}
@@ -0,0 +1,63 @@
+package chainark
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+func TestCommittedVerifier(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit(assert, 2, 0)
+
+	// carrying its own fingerprint, the unit proof passes for a recursive one, along with a placeholder hybrid one
+	selfFps := []common_utils.FingerPrintBytes{unit.fp, GetPlaceholderFp()}
+	proof, pubWitness := unit.proveNative(assert, unit.link(0, selfFps...))
+
+	commitment, err := SelfFpsCommitment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](selfFps)
+	assert.NoError(err)
+	swapped, err := SelfFpsCommitment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		[]common_utils.FingerPrintBytes{selfFps[1], selfFps[0]})
+	assert.NoError(err)
+	assert.NotEqual(commitment, swapped)
+
+	verifier, err := NewCommittedVerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		unit.ccs, 2, 1, 2)
+	assert.NoError(err)
+
+	assign := func(fps []common_utils.FingerPrintBytes) *CommittedVerifier[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl] {
+		a, err := NewCommittedVerifierAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			unit.vk, proof, pubWitness, fps)
+		assert.NoError(err)
+		return a
+	}
+
+	a := assign(selfFps)
+	assert.Equal(new(big.Int).SetBytes(commitment), a.SelfFpsCommitment)
+	assert.NoError(test.IsSolved(verifier, a, ecc.BN254.ScalarField()))
+
+	// the commitment is bound to the SelfFps of the inner witness
+	a = assign(selfFps)
+	a.SelfFpsCommitment = new(big.Int).SetBytes(swapped)
+	assert.Error(test.IsSolved(verifier, a, ecc.BN254.ScalarField()))
+	assert.Error(test.IsSolved(verifier, assign([]common_utils.FingerPrintBytes{selfFps[1], selfFps[0]}), ecc.BN254.ScalarField()))
+}
+
+func TestSelfFpsCommitmentHash(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// the fingerprints of BW6-761 circuits are committed with the MiMC hash of BW6-761, which they fit in
+	ids := testIDs(assert)
+	selfFps := []common_utils.FingerPrintBytes{append(append(common_utils.FingerPrintBytes{1}, ids[0]...), ids[0][:15]...)}
+	commitment, err := SelfFpsCommitment[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](selfFps)
+	assert.NoError(err)
+	assert.Equal(48, len(commitment))
+
+	_, err = SelfFpsCommitment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](selfFps)
+	assert.Error(err)
+}
@@ -0,0 +1,425 @@
+package chainark
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/uints"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * A CompositeID links on a tuple of named fields, such as (block hash, height, validator set hash), each field being a
+ * LinkageID of its own shape. Its bytes are the bytes of its fields in the order of the IDSchema.
+ *
+ * Unit circuits embedding CompositeMultiUnit publish the projection of their ids on a few public fields: the values of
+ * the public fields in the order of the schema, followed by the IDCommitment of the values of the other fields if any.
+ * The projection is a LinkageID of IDCommitmentBits bits per value, so that recursive circuits and the Verifier are
+ * used as is, linking the full ids through the commitment while only the public fields are readable. Note that the
+ * commitment binds the hidden fields but does not hide them.
+ */
+
+type IDField struct {
+	Name       string
+	NbVals     int
+	BitsPerVal int
+}
+
+func (f IDField) nbBytes() int {
+	return f.NbVals * f.BitsPerVal / 8
+}
+
+type IDSchema []IDField
+
+func (s IDSchema) Validate() error {
+	if len(s) == 0 {
+		return fmt.Errorf("%w: empty id schema", ErrIDShapeMismatch)
+	}
+	names := make(map[string]bool)
+	for _, f := range s {
+		if f.Name == "" || names[f.Name] {
+			return fmt.Errorf("%w: id field name %q", ErrIDShapeMismatch, f.Name)
+		}
+		names[f.Name] = true
+		if f.NbVals <= 0 || f.BitsPerVal <= 0 || f.BitsPerVal%8 != 0 {
+			return fmt.Errorf("%w: id field %v of %v vals of %v bits", ErrIDShapeMismatch, f.Name, f.NbVals, f.BitsPerVal)
+		}
+	}
+	return nil
+}
+
+func (s IDSchema) NbBytes() int {
+	n := 0
+	for _, f := range s {
+		n += f.nbBytes()
+	}
+	return n
+}
+
+func (s IDSchema) index(name string) (int, error) {
+	for i, f := range s {
+		if f.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no id field %v", ErrIDShapeMismatch, name)
+}
+
+// public tells for each field of the schema whether it is one of publicFields.
+func (s IDSchema) public(publicFields []string) ([]bool, error) {
+	ret := make([]bool, len(s))
+	for _, name := range publicFields {
+		i, err := s.index(name)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = true
+	}
+	return ret, nil
+}
+
+// Split returns the bytes of each field of an id of the schema.
+func (s IDSchema) Split(data LinkageIDBytes) ([]LinkageIDBytes, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if len(data) != s.NbBytes() {
+		return nil, fmt.Errorf("%w: %v bytes vs %v", ErrIDShapeMismatch, len(data), s.NbBytes())
+	}
+	ret := make([]LinkageIDBytes, len(s))
+	offset := 0
+	for i, f := range s {
+		ret[i] = data[offset : offset+f.nbBytes()]
+		offset += f.nbBytes()
+	}
+	return ret, nil
+}
+
+// Join is the inverse of Split.
+func (s IDSchema) Join(fields ...LinkageIDBytes) (LinkageIDBytes, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if len(fields) != len(s) {
+		return nil, fmt.Errorf("%w: %v fields vs %v", ErrIDShapeMismatch, len(fields), len(s))
+	}
+	ret := make(LinkageIDBytes, 0, s.NbBytes())
+	for i, f := range s {
+		if len(fields[i]) != f.nbBytes() {
+			return nil, fmt.Errorf("%w: field %v of %v bytes vs %v", ErrIDShapeMismatch, f.Name, len(fields[i]), f.nbBytes())
+		}
+		ret = append(ret, fields[i]...)
+	}
+	return ret, nil
+}
+
+type CompositeID struct {
+	Schema IDSchema
+	Fields []LinkageID // in the order of Schema
+}
+
+func PlaceholderCompositeID(schema IDSchema) (CompositeID, error) {
+	if err := schema.Validate(); err != nil {
+		return CompositeID{}, err
+	}
+	fields := make([]LinkageID, len(schema))
+	for i, f := range schema {
+		fields[i] = PlaceholderLinkageID(f.NbVals, f.BitsPerVal)
+	}
+	return CompositeID{Schema: schema, Fields: fields}, nil
+}
+
+func CompositeIDFromBytes(schema IDSchema, data LinkageIDBytes) (CompositeID, error) {
+	fieldBytes, err := schema.Split(data)
+	if err != nil {
+		return CompositeID{}, err
+	}
+	fields := make([]LinkageID, len(schema))
+	for i, f := range schema {
+		fields[i] = LinkageIDFromBytes(fieldBytes[i], f.BitsPerVal)
+	}
+	return CompositeID{Schema: schema, Fields: fields}, nil
+}
+
+// CompositeIDFromU8s is the in-circuit counterpart of CompositeIDFromBytes.
+func CompositeIDFromU8s(api frontend.API, schema IDSchema, data []uints.U8) (CompositeID, error) {
+	if err := schema.Validate(); err != nil {
+		return CompositeID{}, err
+	}
+	if len(data) != schema.NbBytes() {
+		return CompositeID{}, fmt.Errorf("%w: %v bytes vs %v", ErrIDShapeMismatch, len(data), schema.NbBytes())
+	}
+	fields := make([]LinkageID, len(schema))
+	offset := 0
+	for i, f := range schema {
+		fields[i] = LinkageIDFromU8s(api, data[offset:offset+f.nbBytes()], f.BitsPerVal)
+		offset += f.nbBytes()
+	}
+	return CompositeID{Schema: schema, Fields: fields}, nil
+}
+
+func (id CompositeID) Field(name string) (LinkageID, error) {
+	i, err := id.Schema.index(name)
+	if err != nil {
+		return LinkageID{}, err
+	}
+	return id.Fields[i], nil
+}
+
+// FieldToU8s returns the bytes of field name, same as Split does natively.
+func (id CompositeID) FieldToU8s(api frontend.API, name string) ([]uints.U8, error) {
+	f, err := id.Field(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.ToU8s(api), nil
+}
+
+func (id CompositeID) ToU8s(api frontend.API) []uints.U8 {
+	ret := make([]uints.U8, 0, id.Schema.NbBytes())
+	for i := 0; i < len(id.Fields); i++ {
+		ret = append(ret, id.Fields[i].ToU8s(api)...)
+	}
+	return ret
+}
+
+func (id CompositeID) AssertIsEqual(api frontend.API, other CompositeID) error {
+	if err := id.checkShape(other); err != nil {
+		return err
+	}
+	for i := 0; i < len(id.Fields); i++ {
+		err := id.Fields[i].AssertIsEqual(api, other.Fields[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (id CompositeID) IsEqual(api frontend.API, other CompositeID) (frontend.Variable, error) {
+	if err := id.checkShape(other); err != nil {
+		return nil, err
+	}
+	test := frontend.Variable(1)
+	for i := 0; i < len(id.Fields); i++ {
+		t, err := id.Fields[i].IsEqual(api, other.Fields[i])
+		if err != nil {
+			return nil, err
+		}
+		test = api.And(test, t)
+	}
+	return test, nil
+}
+
+func (id CompositeID) checkShape(other CompositeID) error {
+	if len(id.Schema) != len(other.Schema) || len(id.Fields) != len(other.Fields) {
+		return fmt.Errorf("%w: %v fields vs %v", ErrIDShapeMismatch, len(id.Fields), len(other.Fields))
+	}
+	for i := 0; i < len(id.Schema); i++ {
+		if id.Schema[i].Name != other.Schema[i].Name {
+			return fmt.Errorf("%w: field %v vs %v", ErrIDShapeMismatch, id.Schema[i].Name, other.Schema[i].Name)
+		}
+	}
+	return nil
+}
+
+// ProjectionNbVals is the number of values of the projection of the ids of schema on publicFields.
+func ProjectionNbVals(schema IDSchema, publicFields []string) (int, error) {
+	public, err := schema.public(publicFields)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	hidden := false
+	for i, f := range schema {
+		if public[i] {
+			n += f.NbVals
+		} else {
+			hidden = true
+		}
+	}
+	if hidden {
+		n++
+	}
+	return n, nil
+}
+
+func PlaceholderProjectedID[FR emulated.FieldParams](schema IDSchema, publicFields []string) (LinkageID, error) {
+	n, err := ProjectionNbVals(schema, publicFields)
+	if err != nil {
+		return LinkageID{}, err
+	}
+	return PlaceholderLinkageID(n, IDCommitmentBits[FR]()), nil
+}
+
+// ProjectCompositeID returns in circuit the projection of id on publicFields. The native field of api must be FR.
+func ProjectCompositeID[FR emulated.FieldParams](api frontend.API, id CompositeID, publicFields []string) (LinkageID, error) {
+	public, err := id.Schema.public(publicFields)
+	if err != nil {
+		return LinkageID{}, err
+	}
+
+	var vals, hidden []frontend.Variable
+	for i := 0; i < len(id.Fields); i++ {
+		if public[i] {
+			vals = append(vals, id.Fields[i].Vals...)
+		} else {
+			hidden = append(hidden, id.Fields[i].Vals...)
+		}
+	}
+	if len(hidden) != 0 {
+		commitment, err := CommitLinkageID[FR](api, LinkageID{Vals: hidden})
+		if err != nil {
+			return LinkageID{}, err
+		}
+		vals = append(vals, commitment.Vals...)
+	}
+	return LinkageID{
+		Vals:       vals,
+		BitsPerVar: IDCommitmentBits[FR](),
+	}, nil
+}
+
+// ProjectedID returns natively the projection of the id of schema data on publicFields, to be decoded with
+// LinkageIDFromBytes(_, IDCommitmentBits).
+func ProjectedID[FR emulated.FieldParams](schema IDSchema, data LinkageIDBytes, publicFields []string) (LinkageIDBytes, error) {
+	fieldBytes, err := schema.Split(data)
+	if err != nil {
+		return nil, err
+	}
+	public, err := schema.public(publicFields)
+	if err != nil {
+		return nil, err
+	}
+
+	// each value is padded to the size of a field element, public values to be decoded with IDCommitmentBits and
+	// hidden ones to be committed as in circuit
+	bytesPerVal := (IDCommitmentBits[FR]() + 7) / 8
+	var ret, hidden []byte
+	for i, f := range schema {
+		vals := common_utils.ValsFromBytes(fieldBytes[i], f.BitsPerVal)
+		for j := 0; j < len(vals); j++ {
+			v := new(big.Int).SetBytes(vals[j].([]byte)).FillBytes(make([]byte, bytesPerVal))
+			if public[i] {
+				ret = append(ret, v...)
+			} else {
+				hidden = append(hidden, v...)
+			}
+		}
+	}
+	if len(hidden) != 0 {
+		commitment, err := IDCommitment[FR](hidden, bytesPerVal*8)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, commitment...)
+	}
+	return LinkageIDBytes(ret), nil
+}
+
+/**
+ * CompositeMultiUnit is the MultiUnit of composite ids: Begin and End are private, to be constrained by the business
+ * logic, while BeginID and EndID are their projections on PublicFields. To enable counting, set Count and NbLinks as
+ * NewMultiUnitCircuitWithCount and NewMultiUnitAssignmentWithCount do.
+ */
+type CompositeMultiUnit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	BeginID          LinkageID                      `gnark:",public"`
+	EndID            LinkageID                      `gnark:",public"`
+	PlaceHolderFps   []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count            ChainCount                     `gnark:",public"`
+	Begin            CompositeID
+	End              CompositeID
+	PublicFields     []string
+	NbPlaceHolderFps int
+	NbLinks          int
+	Padding          Padding // see NewPadding
+}
+
+func (c *CompositeMultiUnit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginID.Vals[0])
+	if err != nil {
+		return err
+	}
+
+	if c.Count.Enabled() {
+		api.AssertIsEqual(c.Count[0], c.NbLinks)
+	}
+
+	begin, err := ProjectCompositeID[FR](api, c.Begin, c.PublicFields)
+	if err != nil {
+		return err
+	}
+	err = c.BeginID.AssertIsEqual(api, begin)
+	if err != nil {
+		return err
+	}
+	end, err := ProjectCompositeID[FR](api, c.End, c.PublicFields)
+	if err != nil {
+		return err
+	}
+	return c.EndID.AssertIsEqual(api, end)
+}
+
+func NewCompositeMultiUnitCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	schema IDSchema, publicFields []string, nbPlaceHolderFps int,
+) (*CompositeMultiUnit[FR, G1El, G2El, GtEl], error) {
+	projected, err := PlaceholderProjectedID[FR](schema, publicFields)
+	if err != nil {
+		return nil, err
+	}
+	begin, err := PlaceholderCompositeID(schema)
+	if err != nil {
+		return nil, err
+	}
+	end, err := PlaceholderCompositeID(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &CompositeMultiUnit[FR, G1El, G2El, GtEl]{
+		BeginID:          projected,
+		EndID:            PlaceholderLinkageID(len(projected.Vals), projected.BitsPerVar),
+		PlaceHolderFps:   make([]common_utils.FingerPrint[FR], nbPlaceHolderFps),
+		Begin:            begin,
+		End:              end,
+		PublicFields:     publicFields,
+		NbPlaceHolderFps: nbPlaceHolderFps,
+	}, nil
+}
+
+func NewCompositeMultiUnitAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	schema IDSchema, publicFields []string,
+	beginId, endId LinkageIDBytes,
+	nbHolders int,
+) (*CompositeMultiUnit[FR, G1El, G2El, GtEl], error) {
+	begin, err := CompositeIDFromBytes(schema, beginId)
+	if err != nil {
+		return nil, err
+	}
+	end, err := CompositeIDFromBytes(schema, endId)
+	if err != nil {
+		return nil, err
+	}
+	beginProjected, err := ProjectedID[FR](schema, beginId, publicFields)
+	if err != nil {
+		return nil, err
+	}
+	endProjected, err := ProjectedID[FR](schema, endId, publicFields)
+	if err != nil {
+		return nil, err
+	}
+
+	holders := make([]common_utils.FingerPrint[FR], nbHolders)
+	for i := 0; i < nbHolders; i++ {
+		holders[i] = common_utils.FingerPrintFromBytes[FR](GetPlaceholderFp())
+	}
+	return &CompositeMultiUnit[FR, G1El, G2El, GtEl]{
+		BeginID:        LinkageIDFromBytes(beginProjected, IDCommitmentBits[FR]()),
+		EndID:          LinkageIDFromBytes(endProjected, IDCommitmentBits[FR]()),
+		PlaceHolderFps: holders,
+		Begin:          begin,
+		End:            end,
+	}, nil
+}
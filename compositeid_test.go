@@ -0,0 +1,164 @@
+package chainark
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+)
+
+var testSchema = IDSchema{
+	{Name: "hash", NbVals: 2, BitsPerVal: 128},
+	{Name: "height", NbVals: 1, BitsPerVal: 64},
+	{Name: "validators", NbVals: 2, BitsPerVal: 128},
+}
+
+// testCompositeIDs returns 2 ids of testSchema, heights 100 and 101, sharing the same validators
+func testCompositeIDs(assert *test.Assert) []LinkageIDBytes {
+	ids := testIDs(assert)
+	ret := make([]LinkageIDBytes, 0, 2)
+	for i, height := range []byte{100, 101} {
+		id := append(append([]byte{}, ids[2*i]...), 0, 0, 0, 0, 0, 0, 0, height)
+		ret = append(ret, append(id, ids[1]...))
+	}
+	return ret
+}
+
+type testCompositeCircuit struct {
+	Data      []uints.U8
+	ID        CompositeID
+	Projected LinkageID
+
+	PublicFields []string
+}
+
+func (c *testCompositeCircuit) Define(api frontend.API) error {
+	id, err := CompositeIDFromU8s(api, c.ID.Schema, c.Data)
+	if err != nil {
+		return err
+	}
+	err = id.AssertIsEqual(api, c.ID)
+	if err != nil {
+		return err
+	}
+
+	data := c.ID.ToU8s(api)
+	for i := 0; i < len(data); i++ {
+		api.AssertIsEqual(data[i].Val, c.Data[i].Val)
+	}
+	height, err := c.ID.FieldToU8s(api, "height")
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(height); i++ {
+		api.AssertIsEqual(height[i].Val, c.Data[32+i].Val)
+	}
+
+	projected, err := ProjectCompositeID[sw_bn254.ScalarField](api, c.ID, c.PublicFields)
+	if err != nil {
+		return err
+	}
+	return c.Projected.AssertIsEqual(api, projected)
+}
+
+func TestCompositeID(t *testing.T) {
+	assert := test.NewAssert(t)
+	ids := testCompositeIDs(assert)
+
+	fields, err := testSchema.Split(ids[0])
+	assert.NoError(err)
+	assert.Equal(3, len(fields))
+	assert.Equal(8, len(fields[1]))
+	joined, err := testSchema.Join(fields...)
+	assert.NoError(err)
+	assert.Equal(ids[0], joined)
+	_, err = testSchema.Split(ids[0][1:])
+	assert.True(errors.Is(err, ErrIDShapeMismatch))
+	assert.True(errors.Is(IDSchema{{Name: "a", NbVals: 1, BitsPerVal: 12}}.Validate(), ErrIDShapeMismatch))
+	assert.True(errors.Is(append(testSchema, testSchema[0]).Validate(), ErrIDShapeMismatch))
+
+	// 3 public values and the commitment of the validators, whatever the order of the public fields
+	publicFields := []string{"height", "hash"}
+	projected, err := ProjectedID[sw_bn254.ScalarField](testSchema, ids[0], publicFields)
+	assert.NoError(err)
+	assert.Equal(4*32, len(projected))
+	reordered, err := ProjectedID[sw_bn254.ScalarField](testSchema, ids[0], []string{"hash", "height"})
+	assert.NoError(err)
+	assert.Equal(projected, reordered)
+	all, err := ProjectedID[sw_bn254.ScalarField](testSchema, ids[0], []string{"hash", "height", "validators"})
+	assert.NoError(err)
+	assert.Equal(5*32, len(all))
+	_, err = ProjectedID[sw_bn254.ScalarField](testSchema, ids[0], []string{"round"})
+	assert.True(errors.Is(err, ErrIDShapeMismatch))
+
+	placeholder, err := PlaceholderCompositeID(testSchema)
+	assert.NoError(err)
+	placeholderProjected, err := PlaceholderProjectedID[sw_bn254.ScalarField](testSchema, publicFields)
+	assert.NoError(err)
+	id, err := CompositeIDFromBytes(testSchema, ids[0])
+	assert.NoError(err)
+	circuit := &testCompositeCircuit{
+		Data:         make([]uints.U8, testSchema.NbBytes()),
+		ID:           placeholder,
+		Projected:    placeholderProjected,
+		PublicFields: publicFields,
+	}
+	assignment := &testCompositeCircuit{
+		Data:      uints.NewU8Array(ids[0]),
+		ID:        id,
+		Projected: LinkageIDFromBytes(projected, IDCommitmentBits[sw_bn254.ScalarField]()),
+	}
+	assert.NoError(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+
+	assignment.Data = uints.NewU8Array(ids[1])
+	assert.Error(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+}
+
+type testCompositeUnitCircuit struct {
+	*CompositeMultiUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+}
+
+func (c *testCompositeUnitCircuit) Define(api frontend.API) error {
+	// the business logic reads the fields by name
+	beginHeight, err := c.Begin.Field("height")
+	if err != nil {
+		return err
+	}
+	endHeight, err := c.End.Field("height")
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Add(beginHeight.Vals[0], 1), endHeight.Vals[0])
+	return c.CompositeMultiUnit.Define(api)
+}
+
+func TestCompositeUnit(t *testing.T) {
+	assert := test.NewAssert(t)
+	ids := testCompositeIDs(assert)
+	publicFields := []string{"hash", "height"}
+
+	unit, err := NewCompositeMultiUnitCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		testSchema, publicFields, 1)
+	assert.NoError(err)
+	assert.Equal(4, len(unit.BeginID.Vals))
+	circuit := &testCompositeUnitCircuit{CompositeMultiUnit: unit}
+
+	assign := func(begin, end LinkageIDBytes) *testCompositeUnitCircuit {
+		a, err := NewCompositeMultiUnitAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			testSchema, publicFields, begin, end, 1)
+		assert.NoError(err)
+		return &testCompositeUnitCircuit{CompositeMultiUnit: a}
+	}
+
+	assert.NoError(test.IsSolved(circuit, assign(ids[0], ids[1]), ecc.BN254.ScalarField()))
+	assert.Error(test.IsSolved(circuit, assign(ids[1], ids[0]), ecc.BN254.ScalarField()))
+
+	// the hidden validators are bound by the commitment
+	a := assign(ids[0], ids[1])
+	a.End.Fields[2].Vals[0] = 0
+	assert.Error(test.IsSolved(circuit, a, ecc.BN254.ScalarField()))
+}
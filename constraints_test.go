@@ -0,0 +1,34 @@
+package chainark
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// TestConstraintCounts measures the constraint counts given in the README for the native BW6-761 circuits
+func TestConstraintCounts(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	unit := setupTestUnit2Chain(assert, 0)
+	ccs, fps := unit.ccs, []common_utils.FingerPrintBytes{unit.fp}
+
+	recursive, err := NewMultiRecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, ccs, fps, 1)
+	assert.NoError(err)
+	recursiveCcs, err := frontend.Compile(ecc.BW6_761.ScalarField(), scs.NewBuilder, recursive)
+	assert.NoError(err)
+	assert.Equal(552627, recursiveCcs.GetNbConstraints())
+
+	kary, err := NewKaryRecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, ccs, fps, 1, 8)
+	assert.NoError(err)
+	karyCcs, err := frontend.Compile(ecc.BW6_761.ScalarField(), scs.NewBuilder, kary)
+	assert.NoError(err)
+	assert.Equal(2175805, karyCcs.GetNbConstraints())
+}
@@ -0,0 +1,99 @@
+package chainark
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/std/recursion/plonk"
+
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// number of bits allowed for a count, so that summing counts never wraps around the field
+const nbCountBits = 64
+
+/**
+ * ChainCount is an optional public output counting how many links a proof covers. It is either empty (counting
+ * disabled) or holds exactly one value. It is placed after the SelfFps in the public witness, so that the offsets of
+ * the IDs and the fingerprints stay the same whether counting is enabled or not.
+ */
+type ChainCount []frontend.Variable
+
+func PlaceholderChainCount(enabled bool) ChainCount {
+	if !enabled {
+		return nil
+	}
+	return make(ChainCount, 1)
+}
+
+func ChainCountOf(count uint64) ChainCount {
+	return ChainCount{count}
+}
+
+func (cc ChainCount) Enabled() bool {
+	return len(cc) != 0
+}
+
+// CountedUnitCore is to be implemented by UnitCore components used in a HybridCircuit with counting enabled.
+type CountedUnitCore interface {
+	GetNbLinks() int
+}
+
+// unitCount returns the placeholder ChainCount of a circuit verifying proofs of the unit circuits, whose public witness
+// of nbPublic values must have the layout of units exposing a ChainCount iff counted, so that no other public input of
+// a unit could be taken for its count.
+func unitCount(nbPublic, nbIdVals, nbSelfFps int, counted bool) (ChainCount, error) {
+	expected := 2*nbIdVals + nbSelfFps
+	if counted {
+		expected++
+	}
+	if nbPublic != expected {
+		return nil, fmt.Errorf("%w: %v public inputs in the inner circuit, expecting %v", ErrWitnessShape, nbPublic, expected)
+	}
+	return PlaceholderChainCount(counted), nil
+}
+
+func countOffset(nbIdVals, nbFpVars, nbSelfFps int) int {
+	return 2*nbIdVals + nbFpVars*nbSelfFps
+}
+
+// RetrieveCount returns the ChainCount value found in the public witness of an inner proof.
+func RetrieveCount[FR emulated.FieldParams](
+	api frontend.API, witness plonk.Witness[FR], nbIdVals, nbFpVars, nbSelfFps int,
+) frontend.Variable {
+	return retrieveCount[FR](api, witness.Public, nbIdVals, nbFpVars, nbSelfFps)
+}
+
+func retrieveCount[FR emulated.FieldParams](
+	api frontend.API, witnessValues []emulated.Element[FR], nbIdVals, nbFpVars, nbSelfFps int,
+) frontend.Variable {
+	offset := countOffset(nbIdVals, nbFpVars, nbSelfFps)
+	rs := common_utils.RetrieveVarsFromElements(api, witnessValues[offset:offset+1], nbCountBits)
+	return rs[0]
+}
+
+// assertCountSum ensures that count equals the sum of the counts of all the inner proofs plus the extra links
+// verified directly in circuit.
+func assertCountSum[FR emulated.FieldParams](
+	api frontend.API, count ChainCount, nbIdVals, nbSelfFps int, extra int, witnessValues ...[]emulated.Element[FR],
+) {
+	sum := frontend.Variable(extra)
+	for i := 0; i < len(witnessValues); i++ {
+		c := retrieveCount[FR](api, witnessValues[i], nbIdVals, 1, nbSelfFps)
+		sum = api.Add(sum, c)
+	}
+	api.AssertIsEqual(count[0], sum)
+
+	rcheck := rangecheck.New(api)
+	rcheck.Check(count[0], nbCountBits)
+}
+
+func getNbLinks(comp any) (int, error) {
+	counted, ok := comp.(CountedUnitCore)
+	if !ok {
+		return 0, fmt.Errorf("component does not implement CountedUnitCore")
+	}
+	return counted.GetNbLinks(), nil
+}
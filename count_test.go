@@ -0,0 +1,154 @@
+package chainark
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+func TestUnitCount(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	ids := testIDs(assert)
+
+	circuit := NewMultiUnitCircuitWithCount[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		2, 128, 2, 8)
+
+	assignment := NewMultiUnitAssignmentWithCount[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		ids[0], ids[1], 128, 2, 8)
+	err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+	assert.NoError(err)
+
+	assignment = NewMultiUnitAssignmentWithCount[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		ids[0], ids[1], 128, 2, 4)
+	err = test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
+
+// testComp2Chain, counting its links
+type testCountedComp2Chain struct {
+	Comp    *testComp2Chain
+	NbLinks int
+}
+
+func (c *testCountedComp2Chain) Define(api frontend.API) error {
+	return c.Comp.Define(api)
+}
+
+func (c *testCountedComp2Chain) GetBeginID() LinkageID {
+	return c.Comp.BeginID
+}
+
+func (c *testCountedComp2Chain) GetEndID() LinkageID {
+	return c.Comp.EndID
+}
+
+func (c *testCountedComp2Chain) GetNbLinks() int {
+	return c.NbLinks
+}
+
+// a unit circuit with a public input of its own, in place of a ChainCount
+type testExtraPublicUnit2Chain struct {
+	*testUnitCircuit2Chain
+	Extra frontend.Variable `gnark:",public"`
+}
+
+func TestRecursiveCount2Chain(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// each unit proof covers 3 links
+	unit := setupTestUnit2Chain(assert, 3)
+	ids, ccs, fp := unit.ids, unit.ccs, unit.fp
+	selfFps := []common_utils.FingerPrint[sw_bls12377.ScalarField]{unit.recursiveFp()}
+	inner := []testInnerProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine]{
+		unit.prove(assert, unit.link(0)),
+		unit.prove(assert, unit.link(1)),
+	}
+
+	// counting must be stated, and match the layout of the unit circuits
+	_, err := NewMultiRecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, ccs, []common_utils.FingerPrintBytes{fp}, 1)
+	assert.True(errors.Is(err, ErrWitnessShape))
+	extraCcs, err := frontend.Compile(ecc.BLS12_377.ScalarField(), scs.NewBuilder, &testExtraPublicUnit2Chain{
+		testUnitCircuit2Chain: &testUnitCircuit2Chain{
+			MultiUnit: NewMultiUnitCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](2, 128, 1),
+		},
+	})
+	assert.NoError(err)
+	_, err = NewMultiRecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, extraCcs, []common_utils.FingerPrintBytes{fp}, 1)
+	assert.True(errors.Is(err, ErrWitnessShape))
+
+	// the recursive count is the sum of the inner counts
+	recursive, err := NewMultiRecursiveCircuitWithCount[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, ccs, []common_utils.FingerPrintBytes{fp}, 1)
+	assert.NoError(err)
+	for _, count := range []uint64{6, 5} {
+		assignment := NewMultiRecursiveAssignmentWithCount[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			inner[0].vk, inner[1].vk, inner[0].proof, inner[1].proof, inner[0].witness, inner[1].witness, selfFps,
+			LinkageIDFromBytes(ids[0], 128), LinkageIDFromBytes(ids[1], 128), LinkageIDFromBytes(ids[2], 128), count)
+		err = test.IsSolved(recursive, assignment, ecc.BW6_761.ScalarField())
+		if count == 6 {
+			assert.NoError(err)
+		} else {
+			assert.Error(err)
+		}
+	}
+
+	// the hybrid count adds the links of the component
+	comp := func(beginID, endID LinkageID) *testCountedComp2Chain {
+		return &testCountedComp2Chain{Comp: &testComp2Chain{BeginID: beginID, EndID: endID}, NbLinks: 2}
+	}
+	hybrid, err := NewHybridCircuitWithCount[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, ccs, []common_utils.FingerPrintBytes{fp}, 1, comp(PlaceholderLinkageID(2, 128), PlaceholderLinkageID(2, 128)))
+	assert.NoError(err)
+	for _, count := range []uint64{5, 3} {
+		assignment := NewHybridAssignmentWithCount[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			inner[1].vk, inner[1].proof, inner[1].witness, selfFps,
+			LinkageIDFromBytes(ids[1], 128), LinkageIDFromBytes(ids[2], 128), LinkageIDFromBytes(ids[3], 128),
+			comp(LinkageIDFromBytes(ids[2], 128), LinkageIDFromBytes(ids[3], 128)), count)
+		err = test.IsSolved(hybrid, assignment, ecc.BW6_761.ScalarField())
+		if count == 5 {
+			assert.NoError(err)
+		} else {
+			assert.Error(err)
+		}
+	}
+}
+
+func TestVerifierMinCount(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit(assert, 1, 8)
+	ccs, fps := unit.ccs, []common_utils.FingerPrintBytes{unit.fp}
+
+	// a unit proof of 8 links carrying its own fingerprint, as a recursive proof would
+	proof, pubWitness := unit.proveNative(assert, unit.link(0, unit.fp))
+
+	verifierAssignment, err := NewVerifierAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](unit.vk, proof, pubWitness)
+	assert.NoError(err)
+	for _, minCount := range []uint64{8, 9} {
+		verifier, err := NewVerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](ccs, fps, 2, 1, 1, minCount)
+		assert.NoError(err)
+		err = test.IsSolved(verifier, verifierAssignment, ecc.BN254.ScalarField())
+		if minCount == 8 {
+			assert.NoError(err)
+		} else {
+			assert.Error(err)
+		}
+	}
+
+	// no count to check without a ChainCount
+	uncounted, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &testUnitCircuit{
+		MultiUnit: NewMultiUnitCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](2, 128, 1),
+	})
+	assert.NoError(err)
+	_, err = NewVerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](uncounted, fps, 2, 1, 1, 8)
+	assert.True(errors.Is(err, ErrWitnessShape))
+}
@@ -0,0 +1,22 @@
+package chainark
+
+import "errors"
+
+// sentinel errors returned by chainark, to be matched with errors.Is
+var (
+	ErrInvalidProof    = errors.New("chainark: invalid proof")
+	ErrWitnessShape    = errors.New("chainark: unexpected public witness shape")
+	ErrBeginIDMismatch = errors.New("chainark: begin id mismatch")
+	ErrEndIDMismatch   = errors.New("chainark: end id mismatch")
+	ErrUnknownVkFp     = errors.New("chainark: verifying key fingerprint not in the expected set")
+	ErrSelfFpsMismatch = errors.New("chainark: self fingerprints in witness not as expected")
+
+	ErrIDShapeMismatch  = errors.New("chainark: linkage ids of different shapes")
+	ErrBadSelfFpCount   = errors.New("chainark: bad number of self fingerprints")
+	ErrBadProofCount    = errors.New("chainark: bad number of proofs")
+	ErrCommitmentField  = errors.New("chainark: linkage id commitment not supported over this field")
+	ErrBadCompCount     = errors.New("chainark: bad number of hybrid components")
+	ErrDomainSize       = errors.New("chainark: circuits of different domain sizes")
+	ErrBadPadding       = errors.New("chainark: bad padding")
+	ErrUnsupportedCurve = errors.New("chainark: curve not supported")
+)
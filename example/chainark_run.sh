@@ -0,0 +1,41 @@
+#!/bin/bash
+
+# same as setup.sh and run.sh, with the chainark command line tool driven by chainark.json
+set -e
+
+mkdir -p testdata
+go build -o chainark ./cmd/chainark
+
+ID0=843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85
+ID8=6bb396a01d83bfa27c7476005eacb6dfd2384fc70a016ce2ee145a28288c234c
+ID12=016f736042472bd002d5620f0032f37e79779ffcc56eee785e4833edee2c9176
+ID14=2741ec6c2ad44e316d513e8b838ad20a7262aeeac02299e5d817c60c4399f0b4
+ID15=65c0875f28da7797071a7870c2b63e84caa028f876674b17f9f25d7c76778634
+ID19=9ac1c5c7da8ef43bcc4cb3071e247cb5c8579a0d8420718669170ade0b04ada1
+ID23=ad057c8b077361d9f5673d5faa0bf4f6c5013bb5fb745339042329976637a705
+
+# for a demo only, see -srs-dir for an SRS of unknown toxic waste
+./chainark setup -unsafe-srs
+./chainark inspect
+
+./chainark prove-unit -unit unit_8 -begin $ID0 -end $ID8 -out unit_0_8
+./chainark prove-unit -unit unit_4 -begin $ID8 -end $ID12 -out unit_8_12
+./chainark prove-unit -unit unit_2 -begin $ID12 -end $ID14 -out unit_12_14
+./chainark prove-unit -unit unit_1 -begin $ID14 -end $ID15 -out unit_14_15
+./chainark prove-unit -unit unit_4 -begin $ID19 -end $ID23 -out unit_19_23
+
+./chainark prove-recursive -first unit_0_8 -first-circuit unit_8 -second unit_8_12 -second-unit unit_4 \
+    -begin $ID0 -relay $ID8 -end $ID12 -out recursive_0_12
+./chainark prove-recursive -first recursive_0_12 -first-circuit recursive -second unit_12_14 -second-unit unit_2 \
+    -begin $ID0 -relay $ID12 -end $ID14 -out recursive_0_14
+./chainark prove-recursive -first recursive_0_14 -first-circuit recursive -second unit_14_15 -second-unit unit_1 \
+    -begin $ID0 -relay $ID14 -end $ID15 -out recursive_0_15
+./chainark verify -proof recursive_0_15 -begin $ID0 -end $ID15
+
+./chainark prove-hybrid -first recursive_0_15 -first-circuit recursive -begin $ID0 -relay $ID15 -end $ID19 -out hybrid_0_19
+./chainark verify -proof hybrid_0_19 -circuit hybrid -begin $ID0 -end $ID19
+
+./chainark prove-recursive -first hybrid_0_19 -first-circuit hybrid -second unit_19_23 -second-unit unit_4 \
+    -begin $ID0 -relay $ID19 -end $ID23 -out recursive_0_23
+./chainark verify -proof recursive_0_23 -begin $ID0 -end $ID23
+./chainark inspect -proof recursive_0_23
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lightec-xyz/chainark/cli"
+	"github.com/lightec-xyz/chainark/example/unit/core"
+)
+
+// chainark proves and verifies chains with the circuits of the example registered below. Applications
+// build their own binary the same way, registering their own unit circuits and hybrid component.
+func main() {
+	r := cli.NewRegistry()
+	err := core.Register(r)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(cli.Main(r, os.Args[1:]))
+}
@@ -11,6 +11,8 @@ const HybridVkFile = "hybrid.vk"
 const VerifierCcsFile = "verifier.ccs"
 const VerifierPkFile = "verifier.pk"
 const VerifierVkFile = "verifier.vk"
+const VerifierSolFile = "PlonkVerifier.sol"
+const WrapperSolFile = "ChainarkVerifier.sol"
 
 const NbBitsPerIDVal = 128
 const NbIDVals = 2 // linkage id is sha256, thus 256 bits = 128 * 2
@@ -10,6 +10,7 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
@@ -30,7 +31,8 @@ func main() {
 		fmt.Println("usage: ./recursive setup [optimization]")
 		fmt.Println("usage: ./recursive prove firstProof firstWitness secondProof secondWitness beginID relayID endID beginIndex endIndex")
 		fmt.Println("usage: ./recursive provehybrid firstProof firstWitness beginID relayID endID beginIndex endIndex")
-		fmt.Println("usage: ./recursive verify proof witness beginID endID beginIndex endIndex")
+		fmt.Println("usage: ./recursive verify proof witness beginID endID beginIndex endIndex [solidity]")
+		fmt.Println("usage: ./recursive solidity")
 		return
 	}
 
@@ -38,21 +40,22 @@ func main() {
 	flag.NewFlagSet("prove", flag.ExitOnError)
 	flag.NewFlagSet("provehybrid", flag.ExitOnError)
 	flag.NewFlagSet("verify", flag.ExitOnError)
+	flag.NewFlagSet("solidity", flag.ExitOnError)
 
 	switch os.Args[1] {
 	case "setup":
 		{
-			extra := 0
+			var domainSize uint64
 			var err error
 			if len(os.Args) >= 3 {
-				extra, err = strconv.Atoi(os.Args[2])
+				domainSize, err = strconv.ParseUint(os.Args[2], 10, 64)
 				if err != nil {
-					fmt.Printf("extra must be integer: %s\n", os.Args[2])
+					fmt.Printf("domainSize must be integer: %s\n", os.Args[2])
 					return
 				}
 			}
 
-			setup(extra)
+			setup(domainSize)
 		}
 	case "prove":
 		prove(os.Args[2:])
@@ -60,17 +63,42 @@ func main() {
 		hybrid(os.Args[2:])
 	case "verify":
 		verify(os.Args[2:])
+	case "solidity":
+		exportSolidity()
 	default:
-		fmt.Println("usage: ./recursive setup [extra]")
+		fmt.Println("usage: ./recursive setup [domainSize]")
 		fmt.Println("usage: ./recursive prove firstVkFile firstProofFile firstWitFile secondProofFile secondWitFile beginID relayID endID beginIndex relayIndex endIndex")
 		fmt.Println("usage: ./recursive provehybrid firstProof firstWitness beginID relayID endID beginIndex endIndex")
-		fmt.Println("usage: ./recursive verify proof witness beginID endID beginIndex endIndex")
+		fmt.Println("usage: ./recursive verify proof witness beginID endID beginIndex endIndex [solidity]")
+		fmt.Println("usage: ./recursive solidity")
 		return
 	}
 }
 
-func setup(extra int) {
+// compilePadded compiles circuit, padded to domainSize if not 0
+func compilePadded(circuit frontend.Circuit, padding *chainark.Padding, domainSize uint64) constraint.ConstraintSystem {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit)
+	if err != nil {
+		panic(err)
+	}
+	if domainSize == 0 {
+		return ccs
+	}
+
+	*padding, err = chainark.NewPadding(ccs, domainSize)
+	if err != nil {
+		panic(err)
+	}
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit)
+	if err != nil {
+		panic(err)
+	}
+	return ccs
+}
+
+func setup(domainSize uint64) {
 	var unitVkFps []common_utils.FingerPrintBytes
+	ccss := make(map[string]constraint.ConstraintSystem)
 	for i := 3; i >= 0; i-- {
 		n := 1 << i
 		vk, err := operations.ReadVk(filepath.Join(dataDir, utils.UnitVkFile(n)))
@@ -83,20 +111,45 @@ func setup(extra int) {
 			panic(err)
 		}
 		unitVkFps = append(unitVkFps, common_utils.FingerPrintBytes(fp))
+
+		ccss[utils.UnitCcsFile(n)], err = operations.ReadCcs(filepath.Join(dataDir, utils.UnitCcsFile(n)))
+		if err != nil {
+			panic(err)
+		}
 	}
+	unitCcs := ccss[utils.UnitCcsFile(1)]
 
-	unitCcs, err := operations.ReadCcs(filepath.Join(dataDir, utils.UnitCcsFile(1)))
+	recursiveCircuit, err := chainark.NewMultiRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		common.NbIDVals, common.NbBitsPerIDVal,
+		unitCcs, unitVkFps, 2, domainSize > 0)
 	if err != nil {
 		panic(err)
 	}
+	recursiveCcs := compilePadded(recursiveCircuit, &recursiveCircuit.Padding, domainSize)
+	ccss[common.RecursiveCcsFile] = recursiveCcs
 
-	recursiveCircuit := chainark.NewMultiRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+	iter := core.NewIteratedHashCircuit(4) // just an example, not meant to be full
+	hybridCircuit, err := chainark.NewHybridCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
 		common.NbIDVals, common.NbBitsPerIDVal,
-		unitCcs, unitVkFps, 2, extra > 0)
-	recursiveCcs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, recursiveCircuit)
+		unitCcs, unitVkFps, 2, iter, domainSize > 0)
 	if err != nil {
 		panic(err)
 	}
+	hybridCcs := compilePadded(hybridCircuit, &hybridCircuit.Padding, domainSize)
+	ccss[common.HybridCcsFile] = hybridCcs
+
+	// no keys are written unless the optimization is sound
+	if domainSize > 0 {
+		report, err := chainark.CheckOptimization(ccss)
+		if report != nil {
+			for _, c := range report.Circuits {
+				fmt.Printf("%v: domain size %v\n", c.Name, c.DomainSize)
+			}
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
 
 	srs, srsLagrange, err := unsafekzg.NewSRS(recursiveCcs, unsafekzg.WithFSCache())
 	if err != nil {
@@ -123,15 +176,6 @@ func setup(extra int) {
 	}
 	fmt.Println("saved recursive ccs, pk, vk")
 
-	iter := core.NewIteratedHashCircuit(4, extra) // just an example, not meant to be full
-	hybridCircuit := chainark.NewHybridCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
-		common.NbIDVals, common.NbBitsPerIDVal,
-		unitCcs, unitVkFps, 2, iter)
-	hybridCcs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, hybridCircuit)
-	if err != nil {
-		panic(err)
-	}
-
 	srs, srsLagrange, err = unsafekzg.NewSRS(hybridCcs, unsafekzg.WithFSCache())
 	if err != nil {
 		panic(err)
@@ -169,7 +213,7 @@ func setup(extra int) {
 	verifierCircuit, err := NewRecursiveVerifierCircuit(
 		hybridCcs,
 		[]common_utils.FingerPrintBytes{recursiveFpBytes, hybridFpBytes},
-		common.NbIDVals, 1, 2,
+		common.NbIDVals, 1, 2, domainSize > 0,
 	)
 	verifierCcs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, verifierCircuit)
 	if err != nil {
@@ -547,12 +591,12 @@ func hybrid(args []string) {
 	}
 
 	fmt.Println("saving proof and witness ...")
-	err = operations.WriteProof(proof, filepath.Join(dataDir, fmt.Sprintf("recursive_%v_%v.proof", beignIndex, endIndex)))
+	err = operations.WriteProof(proof, filepath.Join(dataDir, fmt.Sprintf("hybrid_%v_%v.proof", beignIndex, endIndex)))
 	if err != nil {
 		panic(err)
 	}
 
-	err = operations.WriteWitness(pubWitness, filepath.Join(dataDir, fmt.Sprintf("recursive_%v_%v.wtns", beignIndex, endIndex)))
+	err = operations.WriteWitness(pubWitness, filepath.Join(dataDir, fmt.Sprintf("hybrid_%v_%v.wtns", beignIndex, endIndex)))
 	if err != nil {
 		panic(err)
 	}
@@ -12,14 +12,14 @@
 # verify newly generated proof
 ./recursive verify  recursive.vk  recursive_0_15.proof recursive_0_15.wtns  843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85 65c0875f28da7797071a7870c2b63e84caa028f876674b17f9f25d7c76778634 0 15
 
-# generate recursive_0_19.proof and recursive_0_19.wtns
+# generate hybrid_0_19.proof and hybrid_0_19.wtns
 ./recursive provehybrid  recursive.vk  recursive_0_15.proof recursive_0_15.wtns 843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85 65c0875f28da7797071a7870c2b63e84caa028f876674b17f9f25d7c76778634  9ac1c5c7da8ef43bcc4cb3071e247cb5c8579a0d8420718669170ade0b04ada1 0 15 19
 
 # verify newly generated proof
-./recursive verify  hybrid.vk  recursive_0_19.proof recursive_0_19.wtns  843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85 9ac1c5c7da8ef43bcc4cb3071e247cb5c8579a0d8420718669170ade0b04ada1 0 19
+./recursive verify  hybrid.vk  hybrid_0_19.proof hybrid_0_19.wtns  843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85 9ac1c5c7da8ef43bcc4cb3071e247cb5c8579a0d8420718669170ade0b04ada1 0 19
 
 # generate recursive_0_23.proof and recursive_0_23.wtns
-./recursive prove  hybrid.vk  recursive_0_19.proof recursive_0_19.wtns  unit_19_23.proof unit_19_23.wtns 843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85 9ac1c5c7da8ef43bcc4cb3071e247cb5c8579a0d8420718669170ade0b04ada1  ad057c8b077361d9f5673d5faa0bf4f6c5013bb5fb745339042329976637a705 0 19 23
+./recursive prove  hybrid.vk  hybrid_0_19.proof hybrid_0_19.wtns  unit_19_23.proof unit_19_23.wtns 843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85 9ac1c5c7da8ef43bcc4cb3071e247cb5c8579a0d8420718669170ade0b04ada1  ad057c8b077361d9f5673d5faa0bf4f6c5013bb5fb745339042329976637a705 0 19 23
 
 # verify the last proof
 ./recursive verify  recursive.vk  recursive_0_23.proof recursive_0_23.wtns  843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85 ad057c8b077361d9f5673d5faa0bf4f6c5013bb5fb745339042329976637a705 0 23
@@ -42,10 +42,11 @@ func TestRecursive_0_12_Simulated(t *testing.T) {
 	unitCcs, err := operations.ReadCcs(filepath.Join(dataDir, utils.UnitCcsFile(1)))
 	assert.NoError(err)
 
-	circuit := chainark.NewRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+	circuit, err := chainark.NewRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
 		common.NbIDVals, common.NbBitsPerIDVal,
 		unitCcs, unitVkFps,
 	)
+	assert.NoError(err)
 
 	_fristVk, err := operations.ReadVk(filepath.Join(dataDir, utils.UnitVkFile(8)))
 	assert.NoError(err)
@@ -131,10 +132,11 @@ func TestRecursive_0_14_Simulated(t *testing.T) {
 	unitCcs, err := operations.ReadCcs(filepath.Join(dataDir, utils.UnitCcsFile(1)))
 	assert.NoError(err)
 
-	circuit := chainark.NewRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+	circuit, err := chainark.NewRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
 		common.NbIDVals, common.NbBitsPerIDVal,
 		unitCcs, unitVkFps,
 	)
+	assert.NoError(err)
 
 	_firstVk, err := operations.ReadVk(filepath.Join(dataDir, common.RecursiveVkFile))
 	assert.NoError(err)
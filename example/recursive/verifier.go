@@ -1,14 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/plonk"
 	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
@@ -26,14 +30,14 @@ import (
 **/
 type RecursiveVerifier struct {
 	*chainark.Verifier[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
-	BeginID chainark.LinkageID //`gnark:",public"`
-	EndID   chainark.LinkageID //`gnark:",public"`
+	BeginID chainark.LinkageID `gnark:",public"` // as the first public inputs, so that the solidity wrapper could decode them
+	EndID   chainark.LinkageID `gnark:",public"`
 }
 
 func (c *RecursiveVerifier) Define(api frontend.API) error {
 	err := c.Verifier.Define(api)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	nbIdVars := len(c.BeginID.Vals)
@@ -46,10 +50,13 @@ func NewRecursiveVerifierCircuit(
 	ccs constraint.ConstraintSystem,
 	vkeyFpsBytes []common_utils.FingerPrintBytes,
 	nbIdVars, nbFpVars, nbSelfFps int,
+	opt ...bool,
 ) (*RecursiveVerifier, error) {
-	v, err := chainark.NewVerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
-		ccs, vkeyFpsBytes, nbIdVars, nbFpVars, nbSelfFps,
-	)
+	newVerifier := chainark.NewVerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+	if len(opt) != 0 && opt[0] {
+		newVerifier = chainark.NewOptimizedVerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+	}
+	v, err := newVerifier(ccs, vkeyFpsBytes, nbIdVars, nbFpVars, nbSelfFps)
 	if err != nil {
 		return nil, err
 	}
@@ -80,9 +87,10 @@ func NewRecursiveVerifierAssignment(beginId, endId chainark.LinkageID,
 
 func verify(args []string) {
 	l := common.NbIDVals * common.NbBitsPerIDVal * 2 / 8
-	if len(args) != 7 {
-		panic("expected 7 parameters")
+	if len(args) != 7 && len(args) != 8 {
+		panic("expected 7 or 8 parameters")
 	}
+	forSolidity := len(args) == 8 && args[7] == "solidity"
 
 	//load vk
 	innerVk, err := operations.ReadVk(filepath.Join(dataDir, args[0]))
@@ -117,6 +125,33 @@ func verify(args []string) {
 		panic(err)
 	}
 
+	// the SelfFps of the inner proof are those of the recursive and hybrid circuits
+	selfFps := make([]common_utils.FingerPrintBytes, 0, 2)
+	for _, vkFile := range []string{common.RecursiveVkFile, common.HybridVkFile} {
+		vk, err := operations.ReadVk(filepath.Join(dataDir, vkFile))
+		if err != nil {
+			panic(err)
+		}
+		fp, err := common_utils.UnsafeFingerPrintFromVk[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](vk)
+		if err != nil {
+			panic(err)
+		}
+		selfFps = append(selfFps, fp)
+	}
+
+	decoded, err := chainark.DecodeChainWitness[sw_bn254.ScalarField](innerWitness, common.NbIDVals*common.NbBitsPerIDVal/8, common.NbBitsPerIDVal, len(selfFps), false)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("begin id in proof: %x\n", decoded.BeginID)
+	fmt.Printf("end id in proof: %x\n", decoded.EndID)
+	for i, fp := range decoded.SelfFps {
+		fmt.Printf("self fp #%v in proof: %x\n", i, fp)
+	}
+	if !bytes.Equal(decoded.BeginID, beginID) || !bytes.Equal(decoded.EndID, endID) {
+		panic("begin or end id in proof not as expected")
+	}
+
 	beignIndex, err := strconv.ParseInt(args[5], 10, 32)
 	if err != nil {
 		panic(err)
@@ -161,20 +196,37 @@ func verify(args []string) {
 		panic(err)
 	}
 
+	proverOpt := recursive_plonk.GetNativeProverOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField())
+	verifierOpt := recursive_plonk.GetNativeVerifierOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField())
+	if forSolidity {
+		proverOpt = solidity.WithProverTargetSolidityVerifier(backend.PLONK)
+		verifierOpt = solidity.WithVerifierTargetSolidityVerifier(backend.PLONK)
+	}
+
 	fmt.Println("proving ...")
-	proof, err := plonk.Prove(ccs, pk, witness,
-		recursive_plonk.GetNativeProverOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField()))
+	proof, err := plonk.Prove(ccs, pk, witness, proverOpt)
 	if err != nil {
 		panic(err)
 	}
 
 	fmt.Println("verifying ...")
-	err = plonk.Verify(proof, vk, pubWitness,
-		recursive_plonk.GetNativeVerifierOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField()))
+	err = plonk.Verify(proof, vk, pubWitness, verifierOpt)
 	if err != nil {
 		panic(err)
 	}
 
+	if forSolidity {
+		calldata, err := chainark.SolidityCalldata(proof, pubWitness)
+		if err != nil {
+			panic(err)
+		}
+		err = os.WriteFile(filepath.Join(dataDir, fmt.Sprintf("verifier_%v_%v.calldata", beignIndex, endIndex)),
+			[]byte(hex.EncodeToString(calldata)), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	fmt.Println("saving proof and witness ...")
 	err = operations.WriteProof(proof, filepath.Join(dataDir, fmt.Sprintf("verifier_%v_%v.proof", beignIndex, endIndex)))
 	if err != nil {
@@ -186,3 +238,31 @@ func verify(args []string) {
 		panic(err)
 	}
 }
+
+func exportSolidity() {
+	vk, err := operations.ReadVk(filepath.Join(dataDir, common.VerifierVkFile))
+	if err != nil {
+		panic(err)
+	}
+
+	verifierFile, err := os.Create(filepath.Join(dataDir, common.VerifierSolFile))
+	if err != nil {
+		panic(err)
+	}
+	defer verifierFile.Close()
+	err = chainark.ExportSolidity(vk, verifierFile)
+	if err != nil {
+		panic(err)
+	}
+
+	wrapperFile, err := os.Create(filepath.Join(dataDir, common.WrapperSolFile))
+	if err != nil {
+		panic(err)
+	}
+	defer wrapperFile.Close()
+	err = chainark.ExportSolidityWrapper(wrapperFile, common.NbIDVals, common.NbBitsPerIDVal)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("saved solidity verifier and wrapper")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/hex"
+	"errors"
 	"log"
 	"path/filepath"
 	"testing"
@@ -63,3 +64,29 @@ func Test_Circuit(t *testing.T) {
 	assert.NoError(err)
 
 }
+
+// a circuit with the public witness shape of a recursive proof: 2 ID values on each side, 2 self fingerprints
+type selfFpsShapeCircuit struct {
+	Public [6]frontend.Variable `gnark:",public"`
+}
+
+func (c *selfFpsShapeCircuit) Define(api frontend.API) error {
+	api.AssertIsDifferent(c.Public[0], 0)
+	return nil
+}
+
+func TestVerifierBadSelfFps(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	innerCcs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &selfFpsShapeCircuit{})
+	assert.NoError(err)
+
+	fps := []common_utils.FingerPrintBytes{chainark.GetPlaceholderFp(), chainark.GetPlaceholderFp()}
+	circuit, err := NewRecursiveVerifierCircuit(innerCcs, fps, 2, 1, 2)
+	assert.NoError(err)
+
+	// a misconfigured wrapper must not compile without the constraints of the verifier
+	circuit.NbSelfFps = 1
+	_, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit)
+	assert.True(errors.Is(err, chainark.ErrBadSelfFpCount))
+}
@@ -1,13 +1,16 @@
 #!/bin/bash
 
+# all the circuits are padded to the same domain size, 2^24, for the optimization
+DOMAIN_SIZE=16777216
+
 mkdir -p testdata
 
 cd unit
 go build .
-./unit setup 150
+./unit setup $DOMAIN_SIZE
 
 cd ../recursive
 go build .
-./recursive setup 50
+./recursive setup $DOMAIN_SIZE
 
 cd ..
@@ -15,7 +15,6 @@ import (
 type UnitCircuit struct {
 	ChainarkComp *chainark.MultiUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
 	nbIter       int
-	extraCost    int
 }
 
 func (c *UnitCircuit) Define(api frontend.API) error {
@@ -26,25 +25,24 @@ func (c *UnitCircuit) Define(api frontend.API) error {
 	}
 
 	iter := IteratedHash{
-		BeginID:   c.ChainarkComp.BeginID,
-		EndID:     c.ChainarkComp.EndID,
-		nbIter:    c.nbIter,
-		extraCost: c.extraCost,
+		BeginID: c.ChainarkComp.BeginID,
+		EndID:   c.ChainarkComp.EndID,
+		nbIter:  c.nbIter,
 	}
 	return iter.Define(api) // taking a shortcut without treating IteratedHash as a circuit
 }
 
-func NewUnitCircuit(n int, extra ...int) *UnitCircuit {
-	ext := 0
-	if len(extra) != 0 {
-		ext = extra[0]
-	}
-	return &UnitCircuit{
+// the optional padding is the number of constraints to add, see chainark.Padding
+func NewUnitCircuit(n int, padding ...int) *UnitCircuit {
+	unit := &UnitCircuit{
 		ChainarkComp: chainark.NewMultiUnitCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
 			common.NbIDVals, common.NbBitsPerIDVal, 2),
-		nbIter:    n,
-		extraCost: ext,
+		nbIter: n,
 	}
+	if len(padding) != 0 {
+		unit.ChainarkComp.Padding = chainark.Padding{NbConstraints: padding[0]}
+	}
+	return unit
 }
 
 func NewUnitAssignement(beginID, endID []byte) *UnitCircuit {
@@ -55,10 +53,9 @@ func NewUnitAssignement(beginID, endID []byte) *UnitCircuit {
 }
 
 type IteratedHash struct {
-	BeginID   chainark.LinkageID
-	EndID     chainark.LinkageID
-	nbIter    int
-	extraCost int
+	BeginID chainark.LinkageID
+	EndID   chainark.LinkageID
+	nbIter  int
 }
 
 func (c *IteratedHash) GetBeginID() chainark.LinkageID {
@@ -69,6 +66,10 @@ func (c *IteratedHash) GetEndID() chainark.LinkageID {
 	return c.EndID
 }
 
+func (c *IteratedHash) GetNbLinks() int {
+	return c.nbIter
+}
+
 func (c *IteratedHash) Define(api frontend.API) error {
 	value := c.BeginID.ToU8s(api)
 
@@ -82,26 +83,14 @@ func (c *IteratedHash) Define(api frontend.API) error {
 		value = s256.Sum()
 	}
 	endID := chainark.LinkageIDFromU8s(api, value, common.NbBitsPerIDVal)
-	c.EndID.AssertIsEqual(api, endID)
-
-	for i := 0; i < c.extraCost; i++ {
-		s256, err := sha256.New(api)
-		if err != nil {
-			return err
-		}
-		s256.Write(uints.NewU8Array(([]byte)("chainark example")))
-		s256.Sum()
-	}
-
-	return nil
+	return c.EndID.AssertIsEqual(api, endID)
 }
 
-func NewIteratedHashCircuit(n, extra int) *IteratedHash {
+func NewIteratedHashCircuit(n int) *IteratedHash {
 	return &IteratedHash{
-		BeginID:   chainark.PlaceholderLinkageID(common.NbIDVals, common.NbBitsPerIDVal),
-		EndID:     chainark.PlaceholderLinkageID(common.NbIDVals, common.NbBitsPerIDVal),
-		nbIter:    n,
-		extraCost: extra,
+		BeginID: chainark.PlaceholderLinkageID(common.NbIDVals, common.NbBitsPerIDVal),
+		EndID:   chainark.PlaceholderLinkageID(common.NbIDVals, common.NbBitsPerIDVal),
+		nbIter:  n,
 	}
 }
 
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/cli"
+	"github.com/lightec-xyz/chainark/example/common"
+)
+
+// Params are the config params of the iterated-hash unit circuit and hybrid component
+type Params struct {
+	NbIter  int `json:"nbIter"`
+	Padding int `json:"padding,omitempty"` // the padding of the unit circuit, as reported by setup with the optimization
+}
+
+// Register registers the example circuits to be used by the chainark command line tool, as each application would do
+// with its own circuits.
+func Register(r *cli.Registry) error {
+	err := r.RegisterUnit("iterated-hash", cli.UnitFactory{
+		Circuit: func(shape cli.Shape, params json.RawMessage) (frontend.Circuit, error) {
+			p, err := parseParams(shape, params)
+			if err != nil {
+				return nil, err
+			}
+			return NewUnitCircuit(p.NbIter, p.Padding), nil
+		},
+		Assignment: func(shape cli.Shape, params json.RawMessage, beginID, endID chainark.LinkageIDBytes) (frontend.Circuit, error) {
+			_, err := parseParams(shape, params)
+			if err != nil {
+				return nil, err
+			}
+			return NewUnitAssignement(beginID, endID), nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterComponent("iterated-hash", cli.ComponentFactory{
+		Component: func(shape cli.Shape, params json.RawMessage) (chainark.UnitCore[cli.FR, cli.G1El, cli.G2El, cli.GtEl], error) {
+			p, err := parseParams(shape, params)
+			if err != nil {
+				return nil, err
+			}
+			return NewIteratedHashCircuit(p.NbIter), nil
+		},
+		Assignment: func(shape cli.Shape, params json.RawMessage, beginID, endID chainark.LinkageIDBytes) (chainark.UnitCore[cli.FR, cli.G1El, cli.G2El, cli.GtEl], error) {
+			_, err := parseParams(shape, params)
+			if err != nil {
+				return nil, err
+			}
+			return NewIteratedHashAssignement(beginID, endID), nil
+		},
+	})
+}
+
+// the example circuits are built with the shape found in the common package
+func parseParams(shape cli.Shape, params json.RawMessage) (*Params, error) {
+	if shape.NbIDVals != common.NbIDVals || shape.BitsPerIDVal != common.NbBitsPerIDVal || shape.NbSelfFps != 2 {
+		return nil, fmt.Errorf("unsupported shape %+v, the example requires a hybrid component and %v ids of %v bits",
+			shape, common.NbIDVals, common.NbBitsPerIDVal)
+	}
+
+	var p Params
+	err := json.Unmarshal(params, &p)
+	if err != nil {
+		return nil, err
+	}
+	if p.NbIter <= 0 {
+		return nil, fmt.Errorf("nbIter must be positive: %v", p.NbIter)
+	}
+	return &p, nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/consensys/gnark/frontend/cs/scs"
 	recursive_plonk "github.com/consensys/gnark/std/recursion/plonk"
 	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/lightec-xyz/chainark"
 	"github.com/lightec-xyz/chainark/example/common"
 	"github.com/lightec-xyz/chainark/example/unit/core"
 	"github.com/lightec-xyz/chainark/example/utils"
@@ -25,7 +26,7 @@ var dataDir = "../testdata"
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("usage: ./unit setup [extra]")
+		fmt.Println("usage: ./unit setup [domainSize]")
 		fmt.Println("usage: ./unit prove beginIdHex endIdHex beginIndex endIndex")
 		return
 	}
@@ -36,41 +37,53 @@ func main() {
 	switch os.Args[1] {
 	case "setup":
 		{
-			extra := 0
+			var domainSize uint64
 			var err error
 			if len(os.Args) >= 3 {
-				extra, err = strconv.Atoi(os.Args[2])
+				domainSize, err = strconv.ParseUint(os.Args[2], 10, 64)
 				if err != nil {
-					fmt.Printf("extra must be integer: %s\n", os.Args[2])
+					fmt.Printf("domainSize must be integer: %s\n", os.Args[2])
 					return
 				}
 			}
-			setup(extra)
+			setup(domainSize)
 		}
 	case "prove":
 		prove(os.Args[2:])
 	default:
-		fmt.Println("usage: ./unit setup [extra]")
+		fmt.Println("usage: ./unit setup [domainSize]")
 		fmt.Println("usage: ./unit prove beginIdHex endIdHex beginIndex endIndex")
 		return
 	}
 }
 
-func NewUnitCcs(n, extra int) constraint.ConstraintSystem {
-	unit := core.NewUnitCircuit(n, extra)
-	unitCcs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, unit)
+// with a domainSize, the unit circuit is padded to it for the optimization
+func NewUnitCcs(n int, domainSize uint64) constraint.ConstraintSystem {
+	unitCcs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, core.NewUnitCircuit(n))
+	if err != nil {
+		panic(err)
+	}
+	if domainSize == 0 {
+		return unitCcs
+	}
+
+	padding, err := chainark.NewPadding(unitCcs, domainSize)
+	if err != nil {
+		panic(err)
+	}
+	unitCcs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, core.NewUnitCircuit(n, padding.NbConstraints))
 	if err != nil {
 		panic(err)
 	}
 	return unitCcs
 }
 
-func setup(extra int) {
+func setup(domainSize uint64) {
 	for i := 3; i >= 0; i-- {
 		n := 1 << i
 		fmt.Printf("setting up for n = %v\n", n)
 
-		ccs := NewUnitCcs(n, extra)
+		ccs := NewUnitCcs(n, domainSize)
 
 		// let's generate the files again
 		srs, srsLagrange, err := unsafekzg.NewSRS(ccs, unsafekzg.WithFSCache())
@@ -0,0 +1,137 @@
+package follower
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/internal/atomicfile"
+	"github.com/lightec-xyz/chainark/planner"
+)
+
+const checkpointVersion = 1
+
+var ErrBadCheckpoint = errors.New("follower: bad checkpoint")
+
+// Checkpoint is the last good recursive or hybrid proof, from the genesis id to the id at index End.
+type Checkpoint struct {
+	GenesisID chainark.LinkageIDBytes
+	EndID     chainark.LinkageIDBytes
+	End       int
+	Tip       planner.Input
+}
+
+type checkpointJSON struct {
+	Version   int    `json:"version"`
+	GenesisID string `json:"genesisId"`
+	EndID     string `json:"endId"`
+	End       int    `json:"end"`
+	Artifact  string `json:"artifact"`
+	Circuit   string `json:"circuit"`
+}
+
+func (cp Checkpoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(checkpointJSON{
+		Version:   checkpointVersion,
+		GenesisID: hex.EncodeToString(cp.GenesisID),
+		EndID:     hex.EncodeToString(cp.EndID),
+		End:       cp.End,
+		Artifact:  cp.Tip.Artifact,
+		Circuit:   cp.Tip.Circuit,
+	})
+}
+
+func (cp *Checkpoint) UnmarshalJSON(data []byte) error {
+	var v checkpointJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadCheckpoint, err)
+	}
+	if v.Version != checkpointVersion {
+		return fmt.Errorf("%w: version %v", ErrBadCheckpoint, v.Version)
+	}
+	genesisID, err := hex.DecodeString(v.GenesisID)
+	if err != nil {
+		return fmt.Errorf("%w: genesis id: %v", ErrBadCheckpoint, err)
+	}
+	endID, err := hex.DecodeString(v.EndID)
+	if err != nil {
+		return fmt.Errorf("%w: end id: %v", ErrBadCheckpoint, err)
+	}
+	if v.End <= 0 || v.Artifact == "" || v.Circuit == "" {
+		return fmt.Errorf("%w: %+v", ErrBadCheckpoint, v)
+	}
+
+	*cp = Checkpoint{
+		GenesisID: genesisID,
+		EndID:     endID,
+		End:       v.End,
+		Tip:       planner.Input{Artifact: v.Artifact, Circuit: v.Circuit},
+	}
+	return nil
+}
+
+// CheckpointStore persists the checkpoint of a follower.
+type CheckpointStore interface {
+	// Load returns the last saved checkpoint, nil if none has been saved.
+	Load() (*Checkpoint, error)
+	Save(cp *Checkpoint) error
+}
+
+// FileCheckpointStore keeps the checkpoint as a json file, replaced atomically so that a crash while saving leaves
+// the previous checkpoint intact.
+type FileCheckpointStore struct {
+	Path string
+}
+
+func (s FileCheckpointStore) Load() (*Checkpoint, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	err = json.Unmarshal(data, &cp)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", s.Path, err)
+	}
+	return &cp, nil
+}
+
+func (s FileCheckpointStore) Save(cp *Checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.Path, data)
+}
+
+// MemoryCheckpointStore keeps the checkpoint in memory, for tests.
+type MemoryCheckpointStore struct {
+	mu sync.Mutex
+	cp *Checkpoint
+}
+
+func (s *MemoryCheckpointStore) Load() (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cp == nil {
+		return nil, nil
+	}
+	cp := *s.cp
+	return &cp, nil
+}
+
+func (s *MemoryCheckpointStore) Save(cp *Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := *cp
+	s.cp = &saved
+	return nil
+}
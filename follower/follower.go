@@ -0,0 +1,189 @@
+package follower
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/orchestrator"
+	"github.com/lightec-xyz/chainark/planner"
+)
+
+/**
+ * The follower keeps the recursive proof of a growing chain up to date. Each step reads the ids appended since the
+ * tip, the last recursive or hybrid proof, plans the jobs extending the tip to the newest ids, and runs them. A
+ * checkpoint is saved as soon as a recursive or hybrid job succeeds, so that a restarted follower resumes from the
+ * last good proof, proving again the unit segments beyond it only.
+ */
+
+var (
+	ErrMismatch = errors.New("follower: ids do not match the checkpoint")
+)
+
+// IDSource provides the ids of the chain, the genesis id being at index 0.
+type IDSource interface {
+	// IDs returns up to max ids from index from, all of them if max <= 0. It returns fewer ids, possibly none, when
+	// the chain is not long enough yet.
+	IDs(ctx context.Context, from, max int) ([]chainark.LinkageIDBytes, error)
+}
+
+type Options struct {
+	Plan         planner.Options
+	Orchestrator orchestrator.Options
+	MaxBatch     int           // maximum number of links proved by a step, 0 for no limit
+	PollInterval time.Duration // delay before polling the source again when there are no new links, defaults to 1 minute
+}
+
+type Service struct {
+	source IDSource
+	prover orchestrator.Prover
+	store  CheckpointStore
+	opts   Options
+}
+
+func New(source IDSource, prover orchestrator.Prover, store CheckpointStore, opts Options) *Service {
+	return &Service{
+		source: source,
+		prover: prover,
+		store:  store,
+		opts:   opts,
+	}
+}
+
+// Tip returns the last saved checkpoint, nil if no recursive proof has been generated yet.
+func (s *Service) Tip() (*Checkpoint, error) {
+	return s.store.Load()
+}
+
+// Step extends the tip to the ids available, up to MaxBatch links. It returns false when there are not enough new
+// ids to plan a step.
+func (s *Service) Step(ctx context.Context) (bool, error) {
+	cp, err := s.store.Load()
+	if err != nil {
+		return false, err
+	}
+
+	var plan *planner.Plan
+	var genesisID chainark.LinkageIDBytes
+	if cp == nil {
+		ids, err := s.fetch(ctx, 0)
+		if err != nil {
+			return false, err
+		}
+		if len(ids) == 0 {
+			return false, nil
+		}
+		genesisID = ids[0]
+		plan, err = largestPlan(ids, func(ids []chainark.LinkageIDBytes) (*planner.Plan, error) {
+			return planner.New(ids, s.opts.Plan)
+		})
+		if err != nil {
+			return false, err
+		}
+	} else {
+		genesis, err := s.source.IDs(ctx, 0, 1)
+		if err != nil {
+			return false, err
+		}
+		if len(genesis) != 1 || !bytes.Equal(genesis[0], cp.GenesisID) {
+			return false, fmt.Errorf("%w: genesis %x", ErrMismatch, cp.GenesisID)
+		}
+		ids, err := s.fetch(ctx, cp.End)
+		if err != nil {
+			return false, err
+		}
+		if len(ids) == 0 || !bytes.Equal(ids[0], cp.EndID) {
+			return false, fmt.Errorf("%w: id %v is not %x", ErrMismatch, cp.End, cp.EndID)
+		}
+		genesisID = cp.GenesisID
+		plan, err = largestPlan(ids, func(ids []chainark.LinkageIDBytes) (*planner.Plan, error) {
+			return planner.Extend(cp.GenesisID, cp.Tip, cp.End, ids, s.opts.Plan)
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if plan == nil {
+		return false, nil
+	}
+
+	var saveErr error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts := s.opts.Orchestrator
+	opts.OnEvent = func(e orchestrator.Event) {
+		if e.Kind == orchestrator.JobSucceeded && e.Job.Kind != planner.KindUnit && saveErr == nil {
+			saveErr = s.store.Save(&Checkpoint{
+				GenesisID: genesisID,
+				EndID:     e.Job.EndID,
+				End:       e.Job.End,
+				Tip:       planner.Input{Artifact: e.Job.Output, Circuit: e.Job.Circuit},
+			})
+			if saveErr != nil {
+				cancel()
+			}
+		}
+		if s.opts.Orchestrator.OnEvent != nil {
+			s.opts.Orchestrator.OnEvent(e)
+		}
+	}
+
+	err = orchestrator.Run(ctx, plan, s.prover, opts)
+	if saveErr != nil {
+		return false, fmt.Errorf("saving the checkpoint: %w", saveErr)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Run steps until ctx is cancelled or a step fails, polling the source every PollInterval for new ids.
+func (s *Service) Run(ctx context.Context) error {
+	interval := s.opts.PollInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	for {
+		progressed, err := s.Step(ctx)
+		if err != nil {
+			return err
+		}
+		if progressed {
+			continue
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// fetch returns the ids from index from, up to MaxBatch links.
+func (s *Service) fetch(ctx context.Context, from int) ([]chainark.LinkageIDBytes, error) {
+	max := 0
+	if s.opts.MaxBatch > 0 {
+		max = s.opts.MaxBatch + 1
+	}
+	return s.source.IDs(ctx, from, max)
+}
+
+// largestPlan plans the longest prefix of ids that could be planned, returning nil if none could.
+func largestPlan(ids []chainark.LinkageIDBytes, plan func([]chainark.LinkageIDBytes) (*planner.Plan, error)) (*planner.Plan, error) {
+	for n := len(ids); n >= 2; n-- {
+		p, err := plan(ids[:n])
+		if err == nil {
+			return p, nil
+		}
+		if !errors.Is(err, planner.ErrNoPlan) && !errors.Is(err, planner.ErrTooShort) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
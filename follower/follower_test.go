@@ -0,0 +1,190 @@
+package follower
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/test"
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/orchestrator"
+	"github.com/lightec-xyz/chainark/planner"
+)
+
+func testIDs(n int) SliceIDSource {
+	ids := make(SliceIDSource, n)
+	for i := 0; i < n; i++ {
+		ids[i] = chainark.LinkageIDBytes{byte(i), byte(i >> 8)}
+	}
+	return ids
+}
+
+func testOptions() Options {
+	return Options{
+		Plan: planner.Options{
+			Units:  []planner.Variant{{Name: "unit_1", NbLinks: 1}, {Name: "unit_4", NbLinks: 4}},
+			Hybrid: &planner.Variant{Name: "hybrid", NbLinks: 2},
+		},
+		Orchestrator: orchestrator.Options{Workers: 2},
+	}
+}
+
+// fakeProver checks that the inputs of each job have been proved, failing the jobs for which fail returns true.
+type fakeProver struct {
+	mu     sync.Mutex
+	proved map[string]bool
+	fail   func(job *planner.Job) bool
+}
+
+func newFakeProver(proved map[string]bool) *fakeProver {
+	return &fakeProver{proved: proved}
+}
+
+func (p *fakeProver) Prove(ctx context.Context, job *planner.Job) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, input := range job.Inputs {
+		if !p.proved[input.Artifact] {
+			return errors.New("input not proved: " + input.Artifact)
+		}
+	}
+	if p.fail != nil && p.fail(job) {
+		return errors.New("prover crashed")
+	}
+	p.proved[job.Output] = true
+	return nil
+}
+
+func TestStep(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	ids := testIDs(30)
+	store := &MemoryCheckpointStore{}
+	prover := newFakeProver(make(map[string]bool))
+
+	// not enough ids for a recursive proof yet
+	s := New(ids[:2], prover, store, testOptions())
+	progressed, err := s.Step(context.Background())
+	assert.NoError(err)
+	assert.False(progressed)
+
+	s = New(ids[:10], prover, store, testOptions())
+	progressed, err = s.Step(context.Background())
+	assert.NoError(err)
+	assert.True(progressed)
+	cp, err := s.Tip()
+	assert.NoError(err)
+	assert.Equal(9, cp.End)
+	assert.Equal(ids[9], cp.EndID)
+	assert.Equal(ids[0], cp.GenesisID)
+	assert.True(prover.proved[cp.Tip.Artifact])
+
+	// nothing new
+	progressed, err = s.Step(context.Background())
+	assert.NoError(err)
+	assert.False(progressed)
+
+	// the chain grows, the tip is extended in batches of at most 8 links
+	opts := testOptions()
+	opts.MaxBatch = 8
+	s = New(ids, prover, store, opts)
+	for _, end := range []int{17, 25, 29} {
+		progressed, err = s.Step(context.Background())
+		assert.NoError(err)
+		assert.True(progressed)
+		cp, err = s.Tip()
+		assert.NoError(err)
+		assert.Equal(end, cp.End)
+		assert.Equal(planner.ArtifactName(planner.Kind(cp.Tip.Circuit), 0, end), cp.Tip.Artifact)
+	}
+
+	// another chain
+	other := testIDs(40)
+	other[0] = chainark.LinkageIDBytes{0xff, 0xff}
+	_, err = New(other, prover, store, opts).Step(context.Background())
+	assert.True(errors.Is(err, ErrMismatch))
+}
+
+func TestRestart(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	ids := testIDs(30)
+	store := FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+	proved := make(map[string]bool)
+
+	// the prover crashes in the middle of the plan
+	prover := newFakeProver(proved)
+	prover.fail = func(job *planner.Job) bool {
+		return job.Kind != planner.KindUnit && job.End > 20
+	}
+	_, err := New(ids, prover, store, testOptions()).Step(context.Background())
+	assert.True(errors.Is(err, orchestrator.ErrJobFailed))
+
+	cp, err := store.Load()
+	assert.NoError(err)
+	assert.NotNil(cp)
+	assert.True(cp.End <= 20)
+	assert.True(cp.End > 0)
+	assert.True(proved[cp.Tip.Artifact])
+	crashed := cp.End
+
+	// restarted, the follower extends the last good proof
+	var started []*planner.Job
+	opts := testOptions()
+	opts.Orchestrator.OnEvent = func(e orchestrator.Event) {
+		if e.Kind == orchestrator.JobStarted {
+			started = append(started, e.Job)
+		}
+	}
+	progressed, err := New(ids, newFakeProver(proved), store, opts).Step(context.Background())
+	assert.NoError(err)
+	assert.True(progressed)
+	for _, job := range started {
+		if job.Kind == planner.KindUnit {
+			assert.True(job.Begin >= crashed)
+		} else {
+			assert.True(job.Relay >= crashed)
+		}
+	}
+
+	cp, err = store.Load()
+	assert.NoError(err)
+	assert.Equal(29, cp.End)
+	assert.Equal(ids[29], cp.EndID)
+}
+
+func TestRun(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	dir := t.TempDir()
+	idsFile := filepath.Join(dir, "ids.txt")
+	assert.NoError(os.WriteFile(idsFile, []byte("0000\n0100\n0200\n"), 0644))
+
+	store := &MemoryCheckpointStore{}
+	opts := testOptions()
+	opts.PollInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	opts.Orchestrator.OnEvent = func(e orchestrator.Event) {
+		if e.Job.End == 5 && e.Kind == orchestrator.JobSucceeded && e.Job.Kind != planner.KindUnit {
+			cancel()
+		}
+	}
+	s := New(FileIDSource{Path: idsFile}, newFakeProver(make(map[string]bool)), store, opts)
+
+	errs := make(chan error)
+	go func() { errs <- s.Run(ctx) }()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(os.WriteFile(idsFile+".new", []byte("0000\n0100\n0200\n0300\n0400\n0500\n"), 0644))
+	assert.NoError(os.Rename(idsFile+".new", idsFile))
+
+	assert.True(errors.Is(<-errs, context.Canceled))
+	cp, err := store.Load()
+	assert.NoError(err)
+	assert.Equal(5, cp.End)
+	assert.Equal(chainark.LinkageIDBytes{5, 0}, cp.EndID)
+}
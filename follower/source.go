@@ -0,0 +1,45 @@
+package follower
+
+import (
+	"context"
+	"os"
+
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/planner"
+)
+
+// FileIDSource reads the ids from a file of hex ids, one per line, to which new ids are appended.
+type FileIDSource struct {
+	Path string
+}
+
+func (s FileIDSource) IDs(ctx context.Context, from, max int) ([]chainark.LinkageIDBytes, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ids, err := planner.ReadIDs(f)
+	if err != nil {
+		return nil, err
+	}
+	return window(ids, from, max), nil
+}
+
+// SliceIDSource serves ids from memory.
+type SliceIDSource []chainark.LinkageIDBytes
+
+func (s SliceIDSource) IDs(ctx context.Context, from, max int) ([]chainark.LinkageIDBytes, error) {
+	return window(s, from, max), nil
+}
+
+func window(ids []chainark.LinkageIDBytes, from, max int) []chainark.LinkageIDBytes {
+	if from >= len(ids) {
+		return nil
+	}
+	ids = ids[from:]
+	if max > 0 && len(ids) > max {
+		ids = ids[:max]
+	}
+	return ids
+}
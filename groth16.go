@@ -0,0 +1,584 @@
+package chainark
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/hash"
+	native_groth16 "github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/std/recursion/groth16"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * The Groth16-inner variants below mirror MultiRecursiveCircuit, HybridCircuit and Verifier, verifying inner proofs
+ * generated with the Groth16 backend (unit circuits compiled with r1cs.NewBuilder), so that every layer of the chain is
+ * proved with Groth16 and its setup per circuit. Their fingerprints are MiMC hashes of the values of the Groth16
+ * verifying keys, which are not comparable with PLONK fingerprints: PLONK and Groth16 proofs could not be mixed in a
+ * chain. To keep PLONK inner layers and only prove the final wrapper with Groth16, see Groth16Wrapper instead.
+ *
+ * For now only BN254 in BN254 is supported:
+ * the constructors and the fingerprint functions return ErrUnsupportedCurve when instantiated with the types of other
+ * curves, or compiled on another field. There is no optimization flag as Groth16 verifying keys do not share a base key.
+ */
+
+type Groth16RecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	BeginID LinkageID `gnark:",public"`
+	RelayID LinkageID
+	EndID   LinkageID `gnark:",public"`
+
+	SelfFps []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count   ChainCount                     `gnark:",public"` // optional, enabled iff the unit circuits have one
+
+	FirstVKey    groth16.VerifyingKey[G1El, G2El, GtEl]
+	FirstProof   groth16.Proof[G1El, G2El]
+	FirstWitness groth16.Witness[FR]
+
+	SecondVKey    groth16.VerifyingKey[G1El, G2El, GtEl]
+	SecondProof   groth16.Proof[G1El, G2El]
+	SecondWitness groth16.Witness[FR]
+
+	// constant values passed from outside
+	ValidUnitFps []common_utils.FingerPrintBytes
+	NbSelfFps    int
+}
+
+func (c *Groth16RecursiveCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	// verify the first vkey
+	rp := recursiveProof[FR, G1El, G2El, GtEl]{
+		beginID:   c.BeginID,
+		endID:     c.RelayID,
+		nbSelfFps: c.NbSelfFps,
+	}
+	firstFp, err := InCircuitGroth16FingerPrint[FR, G1El, G2El, GtEl](api, &c.FirstVKey)
+	if err != nil {
+		return err
+	}
+	rp.assertFpRelations(api, firstFp, c.FirstWitness.Public, c.SelfFps, c.ValidUnitFps)
+
+	// verify the second vkey
+	secondFp, err := InCircuitGroth16FingerPrint[FR, G1El, G2El, GtEl](api, &c.SecondVKey)
+	if err != nil {
+		return err
+	}
+	assertFpInSet[FR](api, secondFp, c.ValidUnitFps)
+
+	assertIds[FR](api, c.BeginID, c.RelayID, c.FirstWitness.Public)
+	assertIds[FR](api, c.RelayID, c.EndID, c.SecondWitness.Public)
+
+	if c.Count.Enabled() {
+		assertCountSum[FR](api, c.Count, len(c.BeginID.Vals), c.NbSelfFps, 0, c.FirstWitness.Public, c.SecondWitness.Public)
+	}
+
+	verifier, err := groth16.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return err
+	}
+
+	err = verifier.AssertProof(c.FirstVKey, c.FirstProof, c.FirstWitness, groth16.WithCompleteArithmetic())
+	if err != nil {
+		return err
+	}
+	return verifier.AssertProof(c.SecondVKey, c.SecondProof, c.SecondWitness, groth16.WithCompleteArithmetic())
+}
+
+func NewGroth16RecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+) (*Groth16RecursiveCircuit[FR, G1El, G2El, GtEl], error) {
+	return newGroth16RecursiveCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, false)
+}
+
+// NewGroth16RecursiveCircuitWithCount is the same as NewGroth16RecursiveCircuit for unit circuits exposing a ChainCount, see
+// NewMultiUnitCircuitWithCount.
+func NewGroth16RecursiveCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+) (*Groth16RecursiveCircuit[FR, G1El, G2El, GtEl], error) {
+	return newGroth16RecursiveCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, true)
+}
+
+func newGroth16RecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	counted bool,
+) (*Groth16RecursiveCircuit[FR, G1El, G2El, GtEl], error) {
+
+	if err := assertGroth16BN254[FR, G1El, G2El, GtEl](); err != nil {
+		return nil, err
+	}
+	if nbSelfFps <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
+	}
+	selfFps := make([]common_utils.FingerPrint[FR], nbSelfFps)
+	// but the constant one wire of the R1CS
+	count, err := unitCount(ccsUnit.GetNbPublicVariables()-1, nbIdVals, nbSelfFps, counted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Groth16RecursiveCircuit[FR, G1El, G2El, GtEl]{
+		BeginID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		RelayID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		EndID:   PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+
+		SelfFps: selfFps,
+		Count:   count,
+
+		FirstVKey:    groth16.PlaceholderVerifyingKey[G1El, G2El, GtEl](ccsUnit),
+		FirstProof:   groth16.PlaceholderProof[G1El, G2El](ccsUnit),
+		FirstWitness: groth16.PlaceholderWitness[FR](ccsUnit),
+
+		SecondVKey:    groth16.PlaceholderVerifyingKey[G1El, G2El, GtEl](ccsUnit),
+		SecondProof:   groth16.PlaceholderProof[G1El, G2El](ccsUnit),
+		SecondWitness: groth16.PlaceholderWitness[FR](ccsUnit),
+
+		ValidUnitFps: unitFpBytes,
+		NbSelfFps:    nbSelfFps,
+	}, nil
+}
+
+func NewGroth16RecursiveAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	firstVkey, secondVkey groth16.VerifyingKey[G1El, G2El, GtEl],
+	firstProof, secondProof groth16.Proof[G1El, G2El],
+	firstWitness, secondWitness groth16.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, endID LinkageID,
+) *Groth16RecursiveCircuit[FR, G1El, G2El, GtEl] {
+	return &Groth16RecursiveCircuit[FR, G1El, G2El, GtEl]{
+		BeginID: beginID,
+		RelayID: relayID,
+		EndID:   endID,
+
+		SelfFps: recursiveFps,
+
+		FirstVKey:    firstVkey,
+		FirstProof:   firstProof,
+		FirstWitness: firstWitness,
+
+		SecondVKey:    secondVkey,
+		SecondProof:   secondProof,
+		SecondWitness: secondWitness,
+	}
+}
+
+// NewGroth16RecursiveAssignmentWithCount is the same as NewGroth16RecursiveAssignment for a circuit created by
+// NewGroth16RecursiveCircuitWithCount, count being the sum of the counts of both inner proofs.
+func NewGroth16RecursiveAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	firstVkey, secondVkey groth16.VerifyingKey[G1El, G2El, GtEl],
+	firstProof, secondProof groth16.Proof[G1El, G2El],
+	firstWitness, secondWitness groth16.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, endID LinkageID,
+	count uint64,
+) *Groth16RecursiveCircuit[FR, G1El, G2El, GtEl] {
+	ret := NewGroth16RecursiveAssignment[FR, G1El, G2El, GtEl](
+		firstVkey, secondVkey, firstProof, secondProof, firstWitness, secondWitness, recursiveFps, beginID, relayID, endID)
+	ret.Count = ChainCountOf(count)
+	return ret
+}
+
+type Groth16HybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	BeginID LinkageID `gnark:",public"`
+	RelayID LinkageID
+	EndID   LinkageID `gnark:",public"`
+
+	SelfFps []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count   ChainCount                     `gnark:",public"` // optional, enabled iff the unit circuits have one
+
+	FirstVKey    groth16.VerifyingKey[G1El, G2El, GtEl]
+	FirstProof   groth16.Proof[G1El, G2El]
+	FirstWitness groth16.Witness[FR]
+
+	SecondComp UnitCore[FR, G1El, G2El, GtEl]
+
+	// constant values passed from outside
+	ValidUnitFps []common_utils.FingerPrintBytes
+	NbSelfFps    int
+}
+
+func (c *Groth16HybridCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	// verify the first vkey
+	rp := recursiveProof[FR, G1El, G2El, GtEl]{
+		beginID:   c.BeginID,
+		endID:     c.RelayID,
+		nbSelfFps: c.NbSelfFps,
+	}
+	firstFp, err := InCircuitGroth16FingerPrint[FR, G1El, G2El, GtEl](api, &c.FirstVKey)
+	if err != nil {
+		return err
+	}
+	rp.assertFpRelations(api, firstFp, c.FirstWitness.Public, c.SelfFps, c.ValidUnitFps)
+
+	assertIds[FR](api, c.BeginID, c.RelayID, c.FirstWitness.Public)
+
+	if c.Count.Enabled() {
+		nbLinks, err := getNbLinks(c.SecondComp)
+		if err != nil {
+			return err
+		}
+		assertCountSum[FR](api, c.Count, len(c.BeginID.Vals), c.NbSelfFps, nbLinks, c.FirstWitness.Public)
+	}
+
+	verifier, err := groth16.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return err
+	}
+
+	err = verifier.AssertProof(c.FirstVKey, c.FirstProof, c.FirstWitness, groth16.WithCompleteArithmetic())
+	if err != nil {
+		return err
+	}
+
+	// linking relayId to endId
+	err = c.RelayID.AssertIsEqual(api, c.SecondComp.GetBeginID())
+	if err != nil {
+		return err
+	}
+	err = c.EndID.AssertIsEqual(api, c.SecondComp.GetEndID())
+	if err != nil {
+		return err
+	}
+
+	return c.SecondComp.Define(api)
+}
+
+func NewGroth16HybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+) (*Groth16HybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newGroth16HybridCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, extraComp, false)
+}
+
+// NewGroth16HybridCircuitWithCount is the same as NewGroth16HybridCircuit for unit circuits exposing a ChainCount, see
+// NewMultiUnitCircuitWithCount.
+func NewGroth16HybridCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+) (*Groth16HybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newGroth16HybridCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, extraComp, true)
+}
+
+func newGroth16HybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	counted bool,
+) (*Groth16HybridCircuit[FR, G1El, G2El, GtEl], error) {
+
+	if err := assertGroth16BN254[FR, G1El, G2El, GtEl](); err != nil {
+		return nil, err
+	}
+	if nbSelfFps <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
+	}
+	selfFps := make([]common_utils.FingerPrint[FR], nbSelfFps)
+	// but the constant one wire of the R1CS
+	count, err := unitCount(ccsUnit.GetNbPublicVariables()-1, nbIdVals, nbSelfFps, counted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Groth16HybridCircuit[FR, G1El, G2El, GtEl]{
+		BeginID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		RelayID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		EndID:   PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+
+		SelfFps: selfFps,
+		Count:   count,
+
+		FirstVKey:    groth16.PlaceholderVerifyingKey[G1El, G2El, GtEl](ccsUnit),
+		FirstProof:   groth16.PlaceholderProof[G1El, G2El](ccsUnit),
+		FirstWitness: groth16.PlaceholderWitness[FR](ccsUnit),
+
+		SecondComp: extraComp,
+
+		ValidUnitFps: unitFpBytes,
+		NbSelfFps:    nbSelfFps,
+	}, nil
+}
+
+func NewGroth16HybridAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	firstVkey groth16.VerifyingKey[G1El, G2El, GtEl],
+	firstProof groth16.Proof[G1El, G2El],
+	firstWitness groth16.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, endID LinkageID,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+) *Groth16HybridCircuit[FR, G1El, G2El, GtEl] {
+	return &Groth16HybridCircuit[FR, G1El, G2El, GtEl]{
+		BeginID: beginID,
+		RelayID: relayID,
+		EndID:   endID,
+
+		SelfFps: recursiveFps,
+
+		FirstVKey:    firstVkey,
+		FirstProof:   firstProof,
+		FirstWitness: firstWitness,
+
+		SecondComp: extraComp,
+	}
+}
+
+// NewGroth16HybridAssignmentWithCount is the same as NewGroth16HybridAssignment for a circuit created by
+// NewGroth16HybridCircuitWithCount, count being the count of the inner proof plus the links of extraComp.
+func NewGroth16HybridAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	firstVkey groth16.VerifyingKey[G1El, G2El, GtEl],
+	firstProof groth16.Proof[G1El, G2El],
+	firstWitness groth16.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, endID LinkageID,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	count uint64,
+) *Groth16HybridCircuit[FR, G1El, G2El, GtEl] {
+	ret := NewGroth16HybridAssignment[FR, G1El, G2El, GtEl](
+		firstVkey, firstProof, firstWitness, recursiveFps, beginID, relayID, endID, extraComp)
+	ret.Count = ChainCountOf(count)
+	return ret
+}
+
+type Groth16Verifier[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	VKey    groth16.VerifyingKey[G1El, G2El, GtEl]
+	Proof   groth16.Proof[G1El, G2El]
+	Witness groth16.Witness[FR]
+
+	// circuit constants
+	VkeyFpsBytes []common_utils.FingerPrintBytes
+	NbIdVars     int
+	NbFpVars     int
+	NbSelfFps    int
+	MinCount     uint64 // if not zero, the inner proof must have a ChainCount of at least MinCount
+}
+
+func (c *Groth16Verifier[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	if c.NbSelfFps != len(c.VkeyFpsBytes) {
+		return fmt.Errorf("%w: %v vs %v vkey fingerprints", ErrBadSelfFpCount, c.NbSelfFps, len(c.VkeyFpsBytes))
+	}
+
+	vkeyFp, err := InCircuitGroth16FingerPrint[FR, G1El, G2El, GtEl](api, &c.VKey)
+	if err != nil {
+		return err
+	}
+
+	assertFpInSet[FR](api, vkeyFp, c.VkeyFpsBytes)
+
+	vkeyFps := make([]common_utils.FingerPrint[FR], len(c.VkeyFpsBytes))
+	for i := 0; i < len(c.VkeyFpsBytes); i++ {
+		vkeyFps[i] = FingerPrintOf[FR](c.VkeyFpsBytes[i])
+	}
+
+	initialOffset := c.NbIdVars * 2
+	nbFpVars := c.NbFpVars
+
+	setTest := testRecursiveFps[FR](api, c.Witness.Public, vkeyFps, initialOffset, nbFpVars, c.NbSelfFps)
+	api.AssertIsEqual(1, setTest)
+
+	if c.MinCount > 0 {
+		count := retrieveCount[FR](api, c.Witness.Public, c.NbIdVars, nbFpVars, c.NbSelfFps)
+		// count is no more than 64 bits, so is the difference unless count < MinCount
+		rcheck := rangecheck.New(api)
+		rcheck.Check(api.Sub(count, c.MinCount), nbCountBits)
+	}
+
+	verifier, err := groth16.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return err
+	}
+
+	return verifier.AssertProof(c.VKey, c.Proof, c.Witness, groth16.WithCompleteArithmetic())
+}
+
+func NewGroth16VerifierCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	ccs constraint.ConstraintSystem,
+	vkeyFpsBytes []common_utils.FingerPrintBytes,
+	nbIdVars, nbFpVars, nbSelfFps int,
+	minCount ...uint64,
+) (*Groth16Verifier[FR, G1El, G2El, GtEl], error) {
+	if err := assertGroth16BN254[FR, G1El, G2El, GtEl](); err != nil {
+		return nil, err
+	}
+	if len(vkeyFpsBytes) != nbSelfFps {
+		return nil, fmt.Errorf("%w: %v vs %v vkey fingerprints", ErrBadSelfFpCount, nbSelfFps, len(vkeyFpsBytes))
+	}
+	minCnt := uint64(0)
+	if len(minCount) != 0 {
+		minCnt = minCount[0]
+	}
+	if minCnt > 0 {
+		// the inner proofs must expose a ChainCount
+		// but the constant one wire of the R1CS
+		_, err := unitCount(ccs.GetNbPublicVariables()-1, nbIdVars, nbFpVars*nbSelfFps, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Groth16Verifier[FR, G1El, G2El, GtEl]{
+		VKey:    groth16.PlaceholderVerifyingKey[G1El, G2El, GtEl](ccs),
+		Proof:   groth16.PlaceholderProof[G1El, G2El](ccs),
+		Witness: groth16.PlaceholderWitness[FR](ccs),
+
+		VkeyFpsBytes: vkeyFpsBytes,
+		NbIdVars:     nbIdVars,
+		NbFpVars:     nbFpVars,
+		NbSelfFps:    nbSelfFps,
+		MinCount:     minCnt,
+	}, nil
+}
+
+func NewGroth16VerifierAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vkey native_groth16.VerifyingKey,
+	proof native_groth16.Proof,
+	witness witness.Witness,
+) (*Groth16Verifier[FR, G1El, G2El, GtEl], error) {
+	if err := assertGroth16BN254[FR, G1El, G2El, GtEl](); err != nil {
+		return nil, err
+	}
+	vk, err := groth16.ValueOfVerifyingKey[G1El, G2El, GtEl](vkey)
+	if err != nil {
+		return nil, err
+	}
+	pf, err := groth16.ValueOfProof[G1El, G2El](proof)
+	if err != nil {
+		return nil, err
+	}
+	wt, err := groth16.ValueOfWitness[FR](witness)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Groth16Verifier[FR, G1El, G2El, GtEl]{
+		VKey:    vk,
+		Proof:   pf,
+		Witness: wt,
+	}, nil
+}
+
+// InCircuitGroth16FingerPrint returns the MiMC hash of a Groth16 VerifyingKey, the counterpart of
+// common_utils.InCircuitFingerPrint for PLONK.
+func InCircuitGroth16FingerPrint[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	api frontend.API, vk *groth16.VerifyingKey[G1El, G2El, GtEl]) (frontend.Variable, error) {
+	var ret frontend.Variable
+	// the native fingerprint is a BN254 MiMC hash
+	if api.Compiler().Field().Cmp(ecc.BN254.ScalarField()) != 0 {
+		return ret, fmt.Errorf("%w: groth16 recursion compiled on %v", ErrUnsupportedCurve, api.Compiler().Field())
+	}
+	vars, err := groth16VkVars[G1El, G2El, GtEl](vk)
+	if err != nil {
+		return ret, err
+	}
+
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return ret, err
+	}
+	h.Write(vars...)
+
+	return h.Sum(), nil
+}
+
+/**
+ * UnsafeGroth16FingerPrintFromVk computes natively the same fingerprint as InCircuitGroth16FingerPrint. Each value is
+ * written as a full block, so that zero values are hashed the same way in and out of circuit.
+ */
+func UnsafeGroth16FingerPrintFromVk[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vk native_groth16.VerifyingKey) ([]byte, error) {
+	if err := assertGroth16BN254[FR, G1El, G2El, GtEl](); err != nil {
+		return nil, err
+	}
+	circuitVk, err := groth16.ValueOfVerifyingKey[G1El, G2El, GtEl](vk)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := groth16VkVars[G1El, G2El, GtEl](&circuitVk)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hash.MIMC_BN254.New()
+	for i := 0; i < len(vars); i++ {
+		var v *big.Int
+		switch t := vars[i].(type) {
+		case *big.Int:
+			v = t
+		case int:
+			v = big.NewInt(int64(t))
+		default:
+			return nil, fmt.Errorf("unexpected vkey value of type %T", t)
+		}
+		h.Write(v.FillBytes(make([]byte, h.BlockSize())))
+	}
+
+	return h.Sum(nil), nil
+}
+
+// groth16VkVars lists all the values of a Groth16 VerifyingKey in the order they are hashed into its fingerprint.
+func groth16VkVars[G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vk *groth16.VerifyingKey[G1El, G2El, GtEl]) ([]frontend.Variable, error) {
+	switch r := any(vk).(type) {
+	case *groth16.VerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]:
+		vars := make([]frontend.Variable, 0)
+		for i := 0; i < len(r.G1.K); i++ {
+			vars = append(vars, r.G1.K[i].X.Limbs...)
+			vars = append(vars, r.G1.K[i].Y.Limbs...)
+		}
+
+		g2s := []sw_bn254.G2Affine{r.G2.GammaNeg, r.G2.DeltaNeg}
+		for i := 0; i < len(r.CommitmentKeys); i++ {
+			g2s = append(g2s, r.CommitmentKeys[i].G, r.CommitmentKeys[i].GSigmaNeg)
+		}
+		for _, g2 := range g2s {
+			vars = append(vars, g2.P.X.A0.Limbs...)
+			vars = append(vars, g2.P.X.A1.Limbs...)
+			vars = append(vars, g2.P.Y.A0.Limbs...)
+			vars = append(vars, g2.P.Y.A1.Limbs...)
+		}
+
+		e := []*emulated.Element[sw_bn254.BaseField]{
+			&r.E.A0, &r.E.A1, &r.E.A2, &r.E.A3, &r.E.A4, &r.E.A5,
+			&r.E.A6, &r.E.A7, &r.E.A8, &r.E.A9, &r.E.A10, &r.E.A11,
+		}
+		for i := 0; i < len(e); i++ {
+			vars = append(vars, e[i].Limbs...)
+		}
+
+		for i := 0; i < len(r.PublicAndCommitmentCommitted); i++ {
+			for j := 0; j < len(r.PublicAndCommitmentCommitted[i]); j++ {
+				vars = append(vars, r.PublicAndCommitmentCommitted[i][j])
+			}
+		}
+		return vars, nil
+	default:
+		return nil, fmt.Errorf("%w: verifying key of type %T", ErrUnsupportedCurve, vk)
+	}
+}
+
+// assertGroth16BN254 returns ErrUnsupportedCurve unless the Groth16 types are those of BN254 proofs verified on BN254.
+func assertGroth16BN254[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT]() error {
+	var fr FR
+	var vk groth16.VerifyingKey[G1El, G2El, GtEl]
+	if _, ok := any(&vk).(*groth16.VerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]); !ok {
+		return fmt.Errorf("%w: verifying key of type %T", ErrUnsupportedCurve, vk)
+	}
+	if fr.Modulus().Cmp(ecc.BN254.ScalarField()) != 0 {
+		return fmt.Errorf("%w: witness field %T", ErrUnsupportedCurve, fr)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package chainark
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	native_groth16 "github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/recursion/groth16"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+type groth16FpCircuit struct {
+	VKey groth16.VerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+	Fp   frontend.Variable
+}
+
+func (c *groth16FpCircuit) Define(api frontend.API) error {
+	fp, err := InCircuitGroth16FingerPrint[sw_bn254.ScalarField](api, &c.VKey)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(fp, c.Fp)
+	return nil
+}
+
+// selfFp is the fingerprint carried by the proof, nil for a unit proof
+func groth16UnitProof(assert *test.Assert, ccs constraint.ConstraintSystem, pk native_groth16.ProvingKey,
+	beginID, endID []byte, selfFp common_utils.FingerPrintBytes) (native_groth16.Proof, witness.Witness) {
+	assignment := &testUnitCircuit{
+		MultiUnit: NewMultiUnitAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](beginID, endID, 128, 1),
+	}
+	if selfFp != nil {
+		assignment.PlaceHolderFps[0] = common_utils.FingerPrintFromBytes[sw_bn254.ScalarField](selfFp)
+	}
+	w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	proof, err := native_groth16.Prove(ccs, pk, w,
+		groth16.GetNativeProverOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField()))
+	assert.NoError(err)
+	pubWitness, err := w.Public()
+	assert.NoError(err)
+	return proof, pubWitness
+}
+
+func TestGroth16Recursive(t *testing.T) {
+	assert := test.NewAssert(t)
+	ids, ccs, pk, vk, fp := groth16TestSetup(assert)
+
+	circuitVk, err := groth16.ValueOfVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](vk)
+	assert.NoError(err)
+
+	// the native fingerprint matches the one computed in circuit
+	err = test.IsSolved(
+		&groth16FpCircuit{VKey: groth16.PlaceholderVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](ccs)},
+		&groth16FpCircuit{VKey: circuitVk, Fp: []byte(fp)},
+		ecc.BN254.ScalarField())
+	assert.NoError(err)
+
+	firstProof, firstWitness := groth16UnitProof(assert, ccs, pk, ids[0], ids[1], nil)
+	secondProof, secondWitness := groth16UnitProof(assert, ccs, pk, ids[1], ids[2], nil)
+
+	unitFps := []common_utils.FingerPrintBytes{fp}
+	circuit, err := NewGroth16RecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		2, 128, ccs, unitFps, 1)
+	assert.NoError(err)
+
+	assign := func(endID []byte) *Groth16RecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl] {
+		firstP, err := groth16.ValueOfProof[sw_bn254.G1Affine, sw_bn254.G2Affine](firstProof)
+		assert.NoError(err)
+		secondP, err := groth16.ValueOfProof[sw_bn254.G1Affine, sw_bn254.G2Affine](secondProof)
+		assert.NoError(err)
+		firstW, err := groth16.ValueOfWitness[sw_bn254.ScalarField](firstWitness)
+		assert.NoError(err)
+		secondW, err := groth16.ValueOfWitness[sw_bn254.ScalarField](secondWitness)
+		assert.NoError(err)
+
+		// any fingerprint other than the unit one and the placeholder one, as if it were the recursive circuit itself
+		selfFp := common_utils.FingerPrintFromBytes[sw_bn254.ScalarField](common_utils.FingerPrintBytes(ids[0][:31]))
+		return NewGroth16RecursiveAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			circuitVk, circuitVk,
+			firstP, secondP,
+			firstW, secondW,
+			[]common_utils.FingerPrint[sw_bn254.ScalarField]{selfFp},
+			LinkageIDFromBytes(ids[0], 128), LinkageIDFromBytes(ids[1], 128), LinkageIDFromBytes(endID, 128),
+		)
+	}
+
+	err = test.IsSolved(circuit, assign(ids[2]), ecc.BN254.ScalarField())
+	assert.NoError(err)
+
+	err = test.IsSolved(circuit, assign(ids[1]), ecc.BN254.ScalarField())
+	assert.Error(err)
+}
+
+// groth16TestSetup is the same as setupTestUnit, with the Groth16 backend
+func groth16TestSetup(assert *test.Assert) ([]LinkageIDBytes, constraint.ConstraintSystem, native_groth16.ProvingKey, native_groth16.VerifyingKey, common_utils.FingerPrintBytes) {
+	unit := &testUnitCircuit{
+		MultiUnit: NewMultiUnitCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](2, 128, 1),
+	}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, unit)
+	assert.NoError(err)
+	pk, vk, err := native_groth16.Setup(ccs)
+	assert.NoError(err)
+	fp, err := UnsafeGroth16FingerPrintFromVk[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](vk)
+	assert.NoError(err)
+	return testIDs(assert), ccs, pk, vk, fp
+}
+
+func TestGroth16Hybrid(t *testing.T) {
+	assert := test.NewAssert(t)
+	ids, ccs, pk, vk, fp := groth16TestSetup(assert)
+
+	circuitVk, err := groth16.ValueOfVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](vk)
+	assert.NoError(err)
+	proof, pubWitness := groth16UnitProof(assert, ccs, pk, ids[0], ids[1], nil)
+	circuitProof, err := groth16.ValueOfProof[sw_bn254.G1Affine, sw_bn254.G2Affine](proof)
+	assert.NoError(err)
+	circuitWitness, err := groth16.ValueOfWitness[sw_bn254.ScalarField](pubWitness)
+	assert.NoError(err)
+
+	circuit, err := NewGroth16HybridCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		2, 128, ccs, []common_utils.FingerPrintBytes{fp}, 1,
+		&testComp2Chain{BeginID: PlaceholderLinkageID(2, 128), EndID: PlaceholderLinkageID(2, 128)})
+	assert.NoError(err)
+
+	assign := func(compBeginID []byte) *Groth16HybridCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl] {
+		// any fingerprint other than the unit one and the placeholder one, as if it were the hybrid circuit itself
+		selfFp := common_utils.FingerPrintFromBytes[sw_bn254.ScalarField](common_utils.FingerPrintBytes(ids[0][:31]))
+		return NewGroth16HybridAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			circuitVk, circuitProof, circuitWitness,
+			[]common_utils.FingerPrint[sw_bn254.ScalarField]{selfFp},
+			LinkageIDFromBytes(ids[0], 128), LinkageIDFromBytes(ids[1], 128), LinkageIDFromBytes(ids[2], 128),
+			&testComp2Chain{BeginID: LinkageIDFromBytes(compBeginID, 128), EndID: LinkageIDFromBytes(ids[2], 128)},
+		)
+	}
+
+	err = test.IsSolved(circuit, assign(ids[1]), ecc.BN254.ScalarField())
+	assert.NoError(err)
+
+	// the component does not start at the relay id
+	err = test.IsSolved(circuit, assign(ids[0]), ecc.BN254.ScalarField())
+	assert.Error(err)
+}
+
+func TestGroth16Verifier(t *testing.T) {
+	assert := test.NewAssert(t)
+	ids, ccs, pk, vk, fp := groth16TestSetup(assert)
+
+	circuit, err := NewGroth16VerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		ccs, []common_utils.FingerPrintBytes{fp}, 2, 1, 1)
+	assert.NoError(err)
+
+	// a unit proof carrying its own fingerprint, as a recursive proof would
+	proof, pubWitness := groth16UnitProof(assert, ccs, pk, ids[0], ids[1], fp)
+	assignment, err := NewGroth16VerifierAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		vk, proof, pubWitness)
+	assert.NoError(err)
+	err = test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+	assert.NoError(err)
+
+	// a unit proof without the expected SelfFps
+	proof, pubWitness = groth16UnitProof(assert, ccs, pk, ids[0], ids[1], nil)
+	assignment, err = NewGroth16VerifierAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		vk, proof, pubWitness)
+	assert.NoError(err)
+	err = test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
+
+func TestGroth16UnsupportedCurve(t *testing.T) {
+	assert := test.NewAssert(t)
+	_, ccs, _, vk, fp := groth16TestSetup(assert)
+	fps := []common_utils.FingerPrintBytes{fp}
+
+	_, err := NewGroth16RecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, ccs, fps, 1)
+	assert.True(errors.Is(err, ErrUnsupportedCurve))
+	_, err = NewGroth16HybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, ccs, fps, 1, &testComp2Chain{BeginID: PlaceholderLinkageID(2, 128), EndID: PlaceholderLinkageID(2, 128)})
+	assert.True(errors.Is(err, ErrUnsupportedCurve))
+	_, err = NewGroth16VerifierCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		ccs, fps, 2, 1, 1)
+	assert.True(errors.Is(err, ErrUnsupportedCurve))
+	_, err = UnsafeGroth16FingerPrintFromVk[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](vk)
+	assert.True(errors.Is(err, ErrUnsupportedCurve))
+}
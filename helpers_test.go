@@ -0,0 +1,154 @@
+package chainark
+
+import (
+	"encoding/hex"
+
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gnark/test/unsafekzg"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// testIDs returns the ids of the test chain, each linked to the next one by the test unit circuits
+func testIDs(assert *test.Assert) []LinkageIDBytes {
+	ids := make([]LinkageIDBytes, 0, 4)
+	for _, s := range []string{
+		"843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b63fef0bf3eb91aa85",
+		"6bb396a01d83bfa27c7476005eacb6dfd2384fc70a016ce2ee145a28288c234c",
+		"2a05a97cf39df75d65bc8aa2bd2e33a7d6f6e4b43c1b62a7c71ab4d1f85a0c1e",
+		"18c4c25dc847bbc76fd3ca67fc4c2028dee5263fddcf01de3faddc20f0462d8f",
+	} {
+		id, err := hex.DecodeString(s)
+		assert.NoError(err)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// an inner proof, as assigned to the circuits verifying it
+type testInnerProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT] struct {
+	vk      plonk.VerifyingKey[FR, G1El, G2El]
+	proof   plonk.Proof[FR, G1El, G2El]
+	witness plonk.Witness[FR]
+}
+
+// a circuit compiled over FR and set up with an unsafe SRS, proving the inner proofs of the tests
+type testProver[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	ids       []LinkageIDBytes
+	ccs       constraint.ConstraintSystem
+	pk        native_plonk.ProvingKey
+	vk        native_plonk.VerifyingKey
+	fp        common_utils.FingerPrintBytes
+	circuitVk plonk.VerifyingKey[FR, G1El, G2El]
+}
+
+func newTestProver[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	assert *test.Assert, circuit frontend.Circuit) *testProver[FR, G1El, G2El, GtEl] {
+	var fr FR
+	ccs, err := frontend.Compile(fr.Modulus(), scs.NewBuilder, circuit)
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs, unsafekzg.WithFSCache())
+	assert.NoError(err)
+	pk, vk, err := native_plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+	fp, err := UnsafeFingerPrintFromVk[FR, G1El, G2El, GtEl](vk)
+	assert.NoError(err)
+	circuitVk, err := plonk.ValueOfVerifyingKey[FR, G1El, G2El](vk)
+	assert.NoError(err)
+	return &testProver[FR, G1El, G2El, GtEl]{
+		ids: testIDs(assert), ccs: ccs, pk: pk, vk: vk, fp: fp, circuitVk: circuitVk,
+	}
+}
+
+// proveNative proves assignment for the circuits verifying proofs over FR
+func (p *testProver[FR, G1El, G2El, GtEl]) proveNative(assert *test.Assert, assignment frontend.Circuit) (native_plonk.Proof, witness.Witness) {
+	var fr FR
+	w, err := frontend.NewWitness(assignment, fr.Modulus())
+	assert.NoError(err)
+	proof, err := native_plonk.Prove(p.ccs, p.pk, w, plonk.GetNativeProverOptions(outerField[FR](), fr.Modulus()))
+	assert.NoError(err)
+	pubWitness, err := w.Public()
+	assert.NoError(err)
+	return proof, pubWitness
+}
+
+// prove is the same as proveNative, returning the proof as assigned to the circuits verifying it
+func (p *testProver[FR, G1El, G2El, GtEl]) prove(assert *test.Assert, assignment frontend.Circuit) testInnerProof[FR, G1El, G2El] {
+	proof, pubWitness := p.proveNative(assert, assignment)
+	circuitProof, err := plonk.ValueOfProof[FR, G1El, G2El](proof)
+	assert.NoError(err)
+	circuitWitness, err := plonk.ValueOfWitness[FR](pubWitness)
+	assert.NoError(err)
+	return testInnerProof[FR, G1El, G2El]{vk: p.circuitVk, proof: circuitProof, witness: circuitWitness}
+}
+
+// a unit circuit of 2 id values of 128 bits, linking the test ids
+type testUnit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	*testProver[FR, G1El, G2El, GtEl]
+	nbSelfFps int
+	count     int // the links covered by each proof, 0 when the unit is not counted
+	wrap      func(*MultiUnit[FR, G1El, G2El, GtEl]) frontend.Circuit
+}
+
+func newTestUnit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	assert *test.Assert, wrap func(*MultiUnit[FR, G1El, G2El, GtEl]) frontend.Circuit,
+	nbSelfFps, count int) *testUnit[FR, G1El, G2El, GtEl] {
+	unit := NewMultiUnitCircuit[FR, G1El, G2El, GtEl](2, 128, nbSelfFps)
+	if count != 0 {
+		unit = NewMultiUnitCircuitWithCount[FR, G1El, G2El, GtEl](2, 128, nbSelfFps, count)
+	}
+	return &testUnit[FR, G1El, G2El, GtEl]{
+		testProver: newTestProver[FR, G1El, G2El, GtEl](assert, wrap(unit)),
+		nbSelfFps:  nbSelfFps,
+		count:      count,
+		wrap:       wrap,
+	}
+}
+
+// setupTestUnit sets up a testUnitCircuit, BN254 in BN254
+func setupTestUnit(assert *test.Assert, nbSelfFps int, count int) *testUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl] {
+	return newTestUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](assert,
+		func(unit *MultiUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]) frontend.Circuit {
+			return &testUnitCircuit{MultiUnit: unit}
+		}, nbSelfFps, count)
+}
+
+// setupTestUnit2Chain sets up a testUnitCircuit2Chain on BLS12-377, to be verified on BW6-761
+func setupTestUnit2Chain(assert *test.Assert, count int) *testUnit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT] {
+	return newTestUnit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](assert,
+		func(unit *MultiUnit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]) frontend.Circuit {
+			return &testUnitCircuit2Chain{MultiUnit: unit}
+		}, 1, count)
+}
+
+// link returns the assignment linking ids[i] to ids[i+1], carrying selfFps, or the placeholder fingerprints when
+// none is given as for a unit proof
+func (u *testUnit[FR, G1El, G2El, GtEl]) link(i int, selfFps ...common_utils.FingerPrintBytes) frontend.Circuit {
+	assignment := NewMultiUnitAssignment[FR, G1El, G2El, GtEl](u.ids[i], u.ids[i+1], 128, u.nbSelfFps)
+	if u.count != 0 {
+		assignment = NewMultiUnitAssignmentWithCount[FR, G1El, G2El, GtEl](u.ids[i], u.ids[i+1], 128, u.nbSelfFps, u.count)
+	}
+	for j := 0; j < len(selfFps); j++ {
+		assignment.PlaceHolderFps[j] = FingerPrintOf[FR](selfFps[j])
+	}
+	return u.wrap(assignment)
+}
+
+// recursiveFp is any fingerprint but the unit and the placeholder ones, standing for the recursive circuit verifying
+// the unit proofs. On the 2-chain, it is as large as the fingerprint of a BW6-761 circuit, which does not fit in FR.
+func (u *testUnit[FR, G1El, G2El, GtEl]) recursiveFp() common_utils.FingerPrint[FR] {
+	var fr FR
+	if outerField[FR]().Cmp(fr.Modulus()) != 0 {
+		return FingerPrintOf[FR](append(append(common_utils.FingerPrintBytes{}, u.ids[3]...), u.ids[3][:16]...))
+	}
+	return FingerPrintOf[FR](common_utils.FingerPrintBytes(u.ids[3][:31]))
+}
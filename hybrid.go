@@ -1,6 +1,8 @@
 package chainark
 
 import (
+	"fmt"
+
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra"
@@ -15,6 +17,7 @@ type HybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebr
 	EndID   LinkageID `gnark:",public"`
 
 	SelfFps []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count   ChainCount                     `gnark:",public"` // optional, enabled iff the unit circuits have one
 
 	FirstVKey    plonk.VerifyingKey[FR, G1El, G2El]
 	FirstProof   plonk.Proof[FR, G1El, G2El]
@@ -25,35 +28,67 @@ type HybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebr
 	// constant values passed from outside
 	ValidUnitFps []common_utils.FingerPrintBytes
 	NbSelfFps    int
+	Padding      Padding // see NewPadding
+
+	committed     bool   // ids are committed, see NewCommittedHybridCircuit
+	optimizedSize uint64 // see assertProofs
 }
 
 func (c *HybridCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginID.Vals[0])
+	if err != nil {
+		return err
+	}
+
 	// verify the first vkey
 	rp := recursiveProof[FR, G1El, G2El, GtEl]{
 		beginID:   c.BeginID,
 		endID:     c.RelayID,
 		nbSelfFps: c.NbSelfFps,
 	}
-	err := rp.assertRelations(api, c.FirstVKey, c.FirstWitness, c.SelfFps, c.ValidUnitFps)
+	err = rp.assertRelations(api, c.FirstVKey, c.FirstWitness, c.SelfFps, c.ValidUnitFps)
 	if err != nil {
 		return err
 	}
 
-	assertIds[FR](api, c.BeginID, c.RelayID, c.FirstWitness)
+	assertIds[FR](api, c.BeginID, c.RelayID, c.FirstWitness.Public)
 
-	verifier, err := plonk.NewVerifier[FR, G1El, G2El, GtEl](api)
-	if err != nil {
-		return err
+	if c.Count.Enabled() {
+		nbLinks, err := getNbLinks(c.SecondComp)
+		if err != nil {
+			return err
+		}
+		assertCountSum[FR](api, c.Count, len(c.BeginID.Vals), c.NbSelfFps, nbLinks, c.FirstWitness.Public)
 	}
 
-	err = verifier.AssertProof(c.FirstVKey, c.FirstProof, c.FirstWitness, plonk.WithCompleteArithmetic())
+	err = assertProofs[FR, G1El, G2El, GtEl](api, c.optimizedSize,
+		[]plonk.VerifyingKey[FR, G1El, G2El]{c.FirstVKey},
+		[]plonk.Proof[FR, G1El, G2El]{c.FirstProof},
+		[]plonk.Witness[FR]{c.FirstWitness})
 	if err != nil {
 		return err
 	}
 
 	// linking relayId to endId
-	c.RelayID.AssertIsEqual(api, c.SecondComp.GetBeginID())
-	c.EndID.AssertIsEqual(api, c.SecondComp.GetEndID())
+	compBeginID, compEndID := c.SecondComp.GetBeginID(), c.SecondComp.GetEndID()
+	if c.committed {
+		compBeginID, err = CommitLinkageID[FR](api, compBeginID)
+		if err != nil {
+			return err
+		}
+		compEndID, err = CommitLinkageID[FR](api, compEndID)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.RelayID.AssertIsEqual(api, compBeginID)
+	if err != nil {
+		return err
+	}
+	err = c.EndID.AssertIsEqual(api, compEndID)
+	if err != nil {
+		return err
+	}
 
 	return c.SecondComp.Define(api)
 }
@@ -63,12 +98,36 @@ func NewHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El alg
 	ccsUnit constraint.ConstraintSystem,
 	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
 	extraComp UnitCore[FR, G1El, G2El, GtEl],
-) *HybridCircuit[FR, G1El, G2El, GtEl] {
+	opt ...bool) (*HybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newHybridCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, extraComp, false, opt)
+}
+
+// NewHybridCircuitWithCount is the same as NewHybridCircuit for unit circuits exposing a ChainCount, see
+// NewMultiUnitCircuitWithCount.
+func NewHybridCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	opt ...bool) (*HybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newHybridCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, extraComp, true, opt)
+}
+
+func newHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	counted bool, opt []bool) (*HybridCircuit[FR, G1El, G2El, GtEl], error) {
 
 	if nbSelfFps <= 0 {
-		panic("wrong nbSelfFps")
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
 	}
 	selfFps := make([]common_utils.FingerPrint[FR], nbSelfFps)
+	count, err := unitCount(ccsUnit.GetNbPublicVariables(), nbIdVals, nbSelfFps, counted)
+	if err != nil {
+		return nil, err
+	}
 
 	return &HybridCircuit[FR, G1El, G2El, GtEl]{
 		BeginID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
@@ -76,6 +135,7 @@ func NewHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El alg
 		EndID:   PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
 
 		SelfFps: selfFps,
+		Count:   count,
 
 		FirstVKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccsUnit),
 		FirstProof:   plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit),
@@ -83,9 +143,52 @@ func NewHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El alg
 
 		SecondComp: extraComp,
 
-		ValidUnitFps: unitFpBytes,
-		NbSelfFps:    nbSelfFps,
+		ValidUnitFps:  unitFpBytes,
+		NbSelfFps:     nbSelfFps,
+		optimizedSize: optimizedSize(ccsUnit, opt),
+	}, nil
+}
+
+// NewCommittedHybridCircuit is the same as NewHybridCircuit in the commitment mode: the ids are committed IDs, to be
+// assigned with IDCommitment, while extraComp has plain ids, committed in circuit with the Poseidon2 hash over FR. The
+// hybrid circuit must thus run over FR: on the 2-chain, where it runs over BW6-761, it returns ErrCommitmentField.
+func NewCommittedHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	opt ...bool) (*HybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newCommittedHybridCircuit[FR, G1El, G2El, GtEl](ccsUnit, unitFpBytes, nbSelfFps, extraComp, false, opt)
+}
+
+// NewCommittedHybridCircuitWithCount is the same as NewCommittedHybridCircuit for unit circuits exposing a ChainCount.
+func NewCommittedHybridCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	opt ...bool) (*HybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newCommittedHybridCircuit[FR, G1El, G2El, GtEl](ccsUnit, unitFpBytes, nbSelfFps, extraComp, true, opt)
+}
+
+func newCommittedHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	counted bool, opt []bool) (*HybridCircuit[FR, G1El, G2El, GtEl], error) {
+	_, err := idCommitmentParamsOf[FR]()
+	if err != nil {
+		return nil, err
+	}
+	var fr FR
+	if outerField[FR]().Cmp(fr.Modulus()) != 0 {
+		return nil, fmt.Errorf("%w: committing ids of modulus %v in a circuit over %v", ErrCommitmentField, fr.Modulus(), outerField[FR]())
 	}
+
+	c, err := newHybridCircuit[FR, G1El, G2El, GtEl](1, IDCommitmentBits[FR](), ccsUnit, unitFpBytes, nbSelfFps, extraComp, counted, opt)
+	if err != nil {
+		return nil, err
+	}
+	c.committed = true
+	return c, nil
 }
 
 func NewHybridAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
@@ -110,3 +213,20 @@ func NewHybridAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El
 		SecondComp: extraComp,
 	}
 }
+
+// NewHybridAssignmentWithCount is the same as NewHybridAssignment for a circuit created by NewHybridCircuitWithCount,
+// count being the count of the inner proof plus the links of extraComp.
+func NewHybridAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	firstVkey plonk.VerifyingKey[FR, G1El, G2El],
+	firstProof plonk.Proof[FR, G1El, G2El],
+	firstWitness plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, endID LinkageID,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	count uint64,
+) *HybridCircuit[FR, G1El, G2El, GtEl] {
+	ret := NewHybridAssignment[FR, G1El, G2El, GtEl](
+		firstVkey, firstProof, firstWitness, recursiveFps, beginID, relayID, endID, extraComp)
+	ret.Count = ChainCountOf(count)
+	return ret
+}
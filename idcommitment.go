@@ -0,0 +1,228 @@
+package chainark
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	poseidon2_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/poseidon2"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	poseidon2_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon2"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	poseidon2_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/poseidon2"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	poseidon2 "github.com/consensys/gnark/std/permutation/poseidon2"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * Every value of a LinkageID is a public input, costing emulated field work at each level of recursion. In the
+ * commitment mode, unit circuits publish instead the Poseidon2 hash of their BeginID and EndID, computed in FR, the
+ * native field of the unit circuits. A committed ID is just a LinkageID of a single value of IDCommitmentBits bits,
+ * so that recursive circuits, the Verifier and the native API work unchanged with 1 id value of IDCommitmentBits bits,
+ * the ids being replaced by their IDCommitment. A hybrid circuit hashes the ids of its UnitCore in circuit, which is
+ * only possible when its native field is FR, i.e. not on BW6-761 with BLS12-377 inner proofs.
+ *
+ * The values of the id are absorbed one by one in a Merkle-Damgard construction, starting from 0, over the Poseidon2
+ * permutation of width 2: h = P(h, v)[1] + v.
+ */
+
+type idCommitmentParams struct {
+	curve      ecc.ID
+	d, rf, rp  int
+	seed       string
+	commitVals func(vals []*big.Int) *big.Int
+}
+
+func idCommitmentParamsOf[FR emulated.FieldParams]() (*idCommitmentParams, error) {
+	var fr FR
+	var p *idCommitmentParams
+	switch {
+	case fr.Modulus().Cmp(ecc.BN254.ScalarField()) == 0:
+		p = &idCommitmentParams{curve: ecc.BN254, d: 5, rf: 6, rp: 50}
+	case fr.Modulus().Cmp(ecc.BLS12_377.ScalarField()) == 0:
+		p = &idCommitmentParams{curve: ecc.BLS12_377, d: 17, rf: 6, rp: 26}
+	case fr.Modulus().Cmp(ecc.BW6_761.ScalarField()) == 0:
+		p = &idCommitmentParams{curve: ecc.BW6_761, d: 5, rf: 6, rp: 51}
+	default:
+		return nil, fmt.Errorf("%w: modulus %v", ErrCommitmentField, fr.Modulus())
+	}
+	p.seed = fmt.Sprintf("Poseidon2-%v[t=2,rF=%v,rP=%v,d=%v]", p.curve, p.rf, p.rp, p.d)
+
+	switch p.curve {
+	case ecc.BN254:
+		h := poseidon2_bn254.NewHash(2, p.rf, p.rp, p.seed)
+		p.commitVals = func(vals []*big.Int) *big.Int { return commitVals[fr_bn254.Element](h.Permutation, vals) }
+	case ecc.BLS12_377:
+		h := poseidon2_bls12377.NewHash(2, p.rf, p.rp, p.seed)
+		p.commitVals = func(vals []*big.Int) *big.Int { return commitVals[fr_bls12377.Element](h.Permutation, vals) }
+	case ecc.BW6_761:
+		h := poseidon2_bw6761.NewHash(2, p.rf, p.rp, p.seed)
+		p.commitVals = func(vals []*big.Int) *big.Int { return commitVals[fr_bw6761.Element](h.Permutation, vals) }
+	}
+	return p, nil
+}
+
+type permutedElement[T any] interface {
+	*T
+	SetBigInt(v *big.Int) *T
+	BigInt(res *big.Int) *big.Int
+	Add(a, b *T) *T
+}
+
+func commitVals[T any, PT permutedElement[T]](permutation func([]T) error, vals []*big.Int) *big.Int {
+	var acc T
+	for i := 0; i < len(vals); i++ {
+		var v T
+		PT(&v).SetBigInt(vals[i])
+		state := []T{acc, v}
+		permutation(state) // only fails on a state of the wrong width
+		PT(&acc).Add(&state[1], &v)
+	}
+	return PT(&acc).BigInt(new(big.Int))
+}
+
+// IDCommitmentBits is the BitsPerVar of a committed ID, the only value of which is an element of FR, on whole bytes so
+// that the bytes of a committed ID are laid out as a single value, see LinkageIDFromBytes.
+func IDCommitmentBits[FR emulated.FieldParams]() int {
+	var fr FR
+	return (fr.Modulus().BitLen() + 7) / 8 * 8
+}
+
+func PlaceholderCommittedID[FR emulated.FieldParams]() LinkageID {
+	return PlaceholderLinkageID(1, IDCommitmentBits[FR]())
+}
+
+// CommitLinkageID returns in circuit the committed ID of id. The native field of api must be FR.
+func CommitLinkageID[FR emulated.FieldParams](api frontend.API, id LinkageID) (LinkageID, error) {
+	p, err := idCommitmentParamsOf[FR]()
+	if err != nil {
+		return LinkageID{}, err
+	}
+	var fr FR
+	if api.Compiler().Field().Cmp(fr.Modulus()) != 0 {
+		return LinkageID{}, fmt.Errorf("%w: committing in a circuit over %v", ErrCommitmentField, api.Compiler().Field())
+	}
+
+	h := poseidon2.NewHash(2, p.d, p.rf, p.rp, p.seed, p.curve)
+	acc := frontend.Variable(0)
+	for i := 0; i < len(id.Vals); i++ {
+		state := []frontend.Variable{acc, id.Vals[i]}
+		err = h.Permutation(api, state)
+		if err != nil {
+			return LinkageID{}, err
+		}
+		acc = api.Add(state[1], id.Vals[i])
+	}
+	return LinkageID{
+		Vals:       []frontend.Variable{acc},
+		BitsPerVar: IDCommitmentBits[FR](),
+	}, nil
+}
+
+// IDCommitment returns natively the committed ID of id, to be decoded with LinkageIDFromBytes(_, IDCommitmentBits).
+func IDCommitment[FR emulated.FieldParams](id LinkageIDBytes, bitsPerIdVal int) (LinkageIDBytes, error) {
+	p, err := idCommitmentParamsOf[FR]()
+	if err != nil {
+		return nil, err
+	}
+	if bitsPerIdVal <= 0 || len(id) == 0 {
+		return nil, fmt.Errorf("%w: %v bytes for %v bits per value", ErrIDShapeMismatch, len(id), bitsPerIdVal)
+	}
+
+	// the values of id, as LinkageIDFromBytes lays them out
+	var fr FR
+	vals := splitIDBytes(id, bitsPerIdVal)
+	for i := 0; i < len(vals); i++ {
+		if vals[i].Cmp(fr.Modulus()) >= 0 {
+			return nil, fmt.Errorf("%w: id value %v does not fit in the field", ErrCommitmentField, i)
+		}
+	}
+
+	c := p.commitVals(vals)
+	return LinkageIDBytes(c.FillBytes(make([]byte, (IDCommitmentBits[FR]()+7)/8))), nil
+}
+
+/**
+ * CommittedMultiUnit is the MultiUnit of the commitment mode: BeginID and EndID are private, to be constrained by the
+ * business logic as usual, while their commitments take their place in the public witness. To enable counting, set
+ * Count and NbLinks as NewMultiUnitCircuitWithCount and NewMultiUnitAssignmentWithCount do.
+ */
+type CommittedMultiUnit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	BeginCommitment  LinkageID                      `gnark:",public"`
+	EndCommitment    LinkageID                      `gnark:",public"`
+	PlaceHolderFps   []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count            ChainCount                     `gnark:",public"`
+	BeginID          LinkageID
+	EndID            LinkageID
+	NbPlaceHolderFps int
+	NbLinks          int
+	Padding          Padding // see NewPadding
+}
+
+func (c *CommittedMultiUnit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginCommitment.Vals[0])
+	if err != nil {
+		return err
+	}
+
+	if c.Count.Enabled() {
+		api.AssertIsEqual(c.Count[0], c.NbLinks)
+	}
+
+	begin, err := CommitLinkageID[FR](api, c.BeginID)
+	if err != nil {
+		return err
+	}
+	err = c.BeginCommitment.AssertIsEqual(api, begin)
+	if err != nil {
+		return err
+	}
+	end, err := CommitLinkageID[FR](api, c.EndID)
+	if err != nil {
+		return err
+	}
+	return c.EndCommitment.AssertIsEqual(api, end)
+}
+
+func NewCommittedMultiUnitCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal, nbPlaceHolderFps int,
+) *CommittedMultiUnit[FR, G1El, G2El, GtEl] {
+	return &CommittedMultiUnit[FR, G1El, G2El, GtEl]{
+		BeginCommitment:  PlaceholderCommittedID[FR](),
+		EndCommitment:    PlaceholderCommittedID[FR](),
+		PlaceHolderFps:   make([]common_utils.FingerPrint[FR], nbPlaceHolderFps),
+		BeginID:          PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		EndID:            PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		NbPlaceHolderFps: nbPlaceHolderFps,
+	}
+}
+
+func NewCommittedMultiUnitAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	beginId, endId LinkageIDBytes, bitsPerIdVal int,
+	nbHolders int,
+) (*CommittedMultiUnit[FR, G1El, G2El, GtEl], error) {
+	beginCommitment, err := IDCommitment[FR](beginId, bitsPerIdVal)
+	if err != nil {
+		return nil, err
+	}
+	endCommitment, err := IDCommitment[FR](endId, bitsPerIdVal)
+	if err != nil {
+		return nil, err
+	}
+
+	holders := make([]common_utils.FingerPrint[FR], nbHolders)
+	for i := 0; i < nbHolders; i++ {
+		holders[i] = common_utils.FingerPrintFromBytes[FR](GetPlaceholderFp())
+	}
+	return &CommittedMultiUnit[FR, G1El, G2El, GtEl]{
+		BeginCommitment: LinkageIDFromBytes(beginCommitment, IDCommitmentBits[FR]()),
+		EndCommitment:   LinkageIDFromBytes(endCommitment, IDCommitmentBits[FR]()),
+		PlaceHolderFps:  holders,
+		BeginID:         LinkageIDFromBytes(beginId, bitsPerIdVal),
+		EndID:           LinkageIDFromBytes(endId, bitsPerIdVal),
+	}, nil
+}
@@ -0,0 +1,176 @@
+package chainark
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+type testCommitCircuit[FR emulated.FieldParams] struct {
+	ID         LinkageID
+	Commitment LinkageID
+}
+
+func (c *testCommitCircuit[FR]) Define(api frontend.API) error {
+	commitment, err := CommitLinkageID[FR](api, c.ID)
+	if err != nil {
+		return err
+	}
+	return c.Commitment.AssertIsEqual(api, commitment)
+}
+
+func TestIDCommitment(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	id := testIDs(assert)[0]
+
+	// native and in-circuit commitments match, on both fields of the inner proofs
+	bn254Commitment, err := IDCommitment[sw_bn254.ScalarField](id, 128)
+	assert.NoError(err)
+	assert.Equal(32, len(bn254Commitment))
+	assert.NoError(test.IsSolved(
+		&testCommitCircuit[sw_bn254.ScalarField]{
+			ID:         PlaceholderLinkageID(2, 128),
+			Commitment: PlaceholderCommittedID[sw_bn254.ScalarField](),
+		},
+		&testCommitCircuit[sw_bn254.ScalarField]{
+			ID:         LinkageIDFromBytes(id, 128),
+			Commitment: LinkageIDFromBytes(bn254Commitment, IDCommitmentBits[sw_bn254.ScalarField]()),
+		},
+		ecc.BN254.ScalarField()))
+
+	bls12377Commitment, err := IDCommitment[sw_bls12377.ScalarField](id, 128)
+	assert.NoError(err)
+	assert.NotEqual(bn254Commitment, bls12377Commitment)
+	blsCircuit := &testCommitCircuit[sw_bls12377.ScalarField]{
+		ID:         PlaceholderLinkageID(2, 128),
+		Commitment: PlaceholderCommittedID[sw_bls12377.ScalarField](),
+	}
+	blsAssignment := &testCommitCircuit[sw_bls12377.ScalarField]{
+		ID:         LinkageIDFromBytes(id, 128),
+		Commitment: LinkageIDFromBytes(bls12377Commitment, IDCommitmentBits[sw_bls12377.ScalarField]()),
+	}
+	assert.NoError(test.IsSolved(blsCircuit, blsAssignment, ecc.BLS12_377.ScalarField()))
+
+	// the commitment is computed in FR only
+	err = test.IsSolved(blsCircuit, blsAssignment, ecc.BN254.ScalarField())
+	assert.True(errors.Is(err, ErrCommitmentField))
+
+	// the layout of the id matters
+	other, err := IDCommitment[sw_bn254.ScalarField](id, 64)
+	assert.NoError(err)
+	assert.NotEqual(bn254Commitment, other)
+
+	_, err = IDCommitment[sw_bn254.ScalarField](id, 0)
+	assert.True(errors.Is(err, ErrIDShapeMismatch))
+	tooLarge := make([]byte, 32)
+	for i := range tooLarge {
+		tooLarge[i] = 0xff
+	}
+	_, err = IDCommitment[sw_bn254.ScalarField](tooLarge, 256)
+	assert.True(errors.Is(err, ErrCommitmentField))
+}
+
+type testCommittedUnitCircuit struct {
+	*CommittedMultiUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+}
+
+func (c *testCommittedUnitCircuit) Define(api frontend.API) error {
+	x := api.Mul(c.BeginID.Vals[0], c.EndID.Vals[0])
+	api.AssertIsDifferent(api.Add(x, c.BeginID.Vals[1], 7), c.EndID.Vals[1])
+	return c.CommittedMultiUnit.Define(api)
+}
+
+func TestCommittedRecursive(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	unit := newTestProver[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](assert, &testCommittedUnitCircuit{
+		CommittedMultiUnit: NewCommittedMultiUnitCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](2, 128, 1),
+	})
+	assert.Equal(3, unit.ccs.GetNbPublicVariables()) // 2 commitments and 1 fingerprint
+	ids, ccs, fp, circuitVk := unit.ids, unit.ccs, unit.fp, unit.circuitVk
+	bits := IDCommitmentBits[sw_bn254.ScalarField]()
+	commitments := make([]LinkageIDBytes, 3)
+	for i := 0; i < len(commitments); i++ {
+		var err error
+		commitments[i], err = IDCommitment[sw_bn254.ScalarField](ids[i], 128)
+		assert.NoError(err)
+	}
+
+	proofs := make([]plonk.Proof[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine], 2)
+	witnesses := make([]plonk.Witness[sw_bn254.ScalarField], 2)
+	for i := 0; i < 2; i++ {
+		assignment, err := NewCommittedMultiUnitAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			ids[i], ids[i+1], 128, 1)
+		assert.NoError(err)
+		proof, pubWitness := unit.proveNative(assert, &testCommittedUnitCircuit{CommittedMultiUnit: assignment})
+
+		// the public witness holds the commitments in place of the ids
+		decoded, err := DecodeChainWitness[sw_bn254.ScalarField](pubWitness, bits/8, bits, 1, false)
+		assert.NoError(err)
+		assert.Equal(commitments[i], decoded.BeginID)
+		assert.Equal(commitments[i+1], decoded.EndID)
+
+		proofs[i], err = plonk.ValueOfProof[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine](proof)
+		assert.NoError(err)
+		witnesses[i], err = plonk.ValueOfWitness[sw_bn254.ScalarField](pubWitness)
+		assert.NoError(err)
+	}
+
+	// a recursive circuit verifies committed unit proofs with 1 id value of IDCommitmentBits
+	recursive, err := NewMultiRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		1, bits, ccs, []common_utils.FingerPrintBytes{fp}, 1)
+	assert.NoError(err)
+	recursiveFp := common_utils.FingerPrint[sw_bn254.ScalarField]{Val: big.NewInt(1)} // any fp but the placeholder
+	assignment := NewMultiRecursiveAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		circuitVk, circuitVk, proofs[0], proofs[1], witnesses[0], witnesses[1],
+		[]common_utils.FingerPrint[sw_bn254.ScalarField]{recursiveFp},
+		LinkageIDFromBytes(commitments[0], bits), LinkageIDFromBytes(commitments[1], bits), LinkageIDFromBytes(commitments[2], bits))
+	assert.NoError(test.IsSolved(recursive, assignment, ecc.BN254.ScalarField()))
+
+	assignment.EndID = LinkageIDFromBytes(commitments[1], bits)
+	assert.Error(test.IsSolved(recursive, assignment, ecc.BN254.ScalarField()))
+
+	// a committed hybrid circuit commits the plain ids of its component in circuit
+	comp := func(beginID, endID LinkageIDBytes) *testComp2Chain {
+		return &testComp2Chain{BeginID: LinkageIDFromBytes(beginID, 128), EndID: LinkageIDFromBytes(endID, 128)}
+	}
+	hybrid, err := NewCommittedHybridCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		ccs, []common_utils.FingerPrintBytes{fp}, 1, comp(ids[1], ids[2]))
+	assert.NoError(err)
+	assignHybrid := func(compBeginID LinkageIDBytes) *HybridCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl] {
+		return NewHybridAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			circuitVk, proofs[0], witnesses[0],
+			[]common_utils.FingerPrint[sw_bn254.ScalarField]{recursiveFp},
+			LinkageIDFromBytes(commitments[0], bits), LinkageIDFromBytes(commitments[1], bits), LinkageIDFromBytes(commitments[2], bits),
+			comp(compBeginID, ids[2]))
+	}
+	assert.NoError(test.IsSolved(hybrid, assignHybrid(ids[1]), ecc.BN254.ScalarField()))
+	assert.Error(test.IsSolved(hybrid, assignHybrid(ids[0]), ecc.BN254.ScalarField()))
+}
+
+func TestCommittedHybrid2Chain(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	unit := &testUnitCircuit2Chain{
+		MultiUnit: NewMultiUnitCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](2, 128, 1),
+	}
+	ccs, err := frontend.Compile(ecc.BLS12_377.ScalarField(), scs.NewBuilder, unit)
+	assert.NoError(err)
+
+	// the ids of the component could not be committed with the BLS12-377 hash in a circuit over BW6-761
+	comp := &testComp2Chain{BeginID: PlaceholderLinkageID(2, 128), EndID: PlaceholderLinkageID(2, 128)}
+	_, err = NewCommittedHybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		ccs, []common_utils.FingerPrintBytes{GetPlaceholderFp()}, 1, comp)
+	assert.True(errors.Is(err, ErrCommitmentField))
+}
@@ -0,0 +1,28 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/test"
+)
+
+func TestWriteFile(t *testing.T) {
+	assert := test.NewAssert(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+
+	assert.NoError(WriteFile(path, []byte("first")))
+	assert.NoError(WriteFile(path, []byte("second")))
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("second", string(data))
+
+	// no temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	assert.NoError(err)
+	assert.Equal(1, len(entries))
+
+	assert.Error(WriteFile(filepath.Join(dir, "missing", "data.json"), []byte("third")))
+}
@@ -0,0 +1,191 @@
+package chainark
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// KaryRecursiveCircuit generalizes MultiRecursiveCircuit to fold any number of inner proofs in one step,
+// chaining BeginID -> RelayIDs[0] -> ... -> RelayIDs[n-2] -> EndID. The first proof could be a unit proof or
+// a recursive proof, while all the following proofs must be unit proofs. There are at least 2 proofs, a single proof
+// would not extend the chain.
+type KaryRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	BeginID  LinkageID `gnark:",public"`
+	RelayIDs []LinkageID
+	EndID    LinkageID `gnark:",public"`
+
+	SelfFps []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count   ChainCount                     `gnark:",public"` // optional, enabled iff the unit circuits have one
+
+	VKeys     []plonk.VerifyingKey[FR, G1El, G2El]
+	Proofs    []plonk.Proof[FR, G1El, G2El]
+	Witnesses []plonk.Witness[FR]
+
+	// constant values passed from outside
+	ValidUnitFps []common_utils.FingerPrintBytes
+	NbSelfFps    int
+	Padding      Padding // see NewPadding
+
+	optimizedSize uint64 // see assertProofs
+}
+
+func (c *KaryRecursiveCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginID.Vals[0])
+	if err != nil {
+		return err
+	}
+
+	nbProofs := len(c.Proofs)
+
+	ids := make([]LinkageID, 0, nbProofs+1)
+	ids = append(ids, c.BeginID)
+	ids = append(ids, c.RelayIDs...)
+	ids = append(ids, c.EndID)
+
+	// verify the first vkey
+	rp := recursiveProof[FR, G1El, G2El, GtEl]{
+		beginID:   ids[0],
+		endID:     ids[1],
+		nbSelfFps: c.NbSelfFps,
+	}
+	err = rp.assertRelations(api, c.VKeys[0], c.Witnesses[0], c.SelfFps, c.ValidUnitFps)
+	if err != nil {
+		return err
+	}
+
+	// verify the rest vkeys
+	for i := 1; i < nbProofs; i++ {
+		fp, err := common_utils.InCircuitFingerPrint[FR, G1El, G2El](api, &c.VKeys[i])
+		if err != nil {
+			return err
+		}
+		assertFpInSet[FR](api, fp, c.ValidUnitFps)
+	}
+
+	for i := 0; i < nbProofs; i++ {
+		assertIds[FR](api, ids[i], ids[i+1], c.Witnesses[i].Public)
+	}
+
+	if c.Count.Enabled() {
+		witnessValues := make([][]emulated.Element[FR], nbProofs)
+		for i := 0; i < nbProofs; i++ {
+			witnessValues[i] = c.Witnesses[i].Public
+		}
+		assertCountSum[FR](api, c.Count, len(c.BeginID.Vals), c.NbSelfFps, 0, witnessValues...)
+	}
+
+	return assertProofs[FR, G1El, G2El, GtEl](api, c.optimizedSize, c.VKeys, c.Proofs, c.Witnesses)
+}
+
+func NewKaryRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	nbProofs int,
+	opt ...bool) (*KaryRecursiveCircuit[FR, G1El, G2El, GtEl], error) {
+	return newKaryRecursiveCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, nbProofs, false, opt)
+}
+
+// NewKaryRecursiveCircuitWithCount is the same as NewKaryRecursiveCircuit for unit circuits exposing a ChainCount, see
+// NewMultiUnitCircuitWithCount.
+func NewKaryRecursiveCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	nbProofs int,
+	opt ...bool) (*KaryRecursiveCircuit[FR, G1El, G2El, GtEl], error) {
+	return newKaryRecursiveCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, nbProofs, true, opt)
+}
+
+func newKaryRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	nbProofs int,
+	counted bool, opt []bool) (*KaryRecursiveCircuit[FR, G1El, G2El, GtEl], error) {
+
+	if nbSelfFps <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
+	}
+	if nbProofs < 2 {
+		return nil, fmt.Errorf("%w: %v", ErrBadProofCount, nbProofs)
+	}
+	selfFps := make([]common_utils.FingerPrint[FR], nbSelfFps)
+	count, err := unitCount(ccsUnit.GetNbPublicVariables(), nbIdVals, nbSelfFps, counted)
+	if err != nil {
+		return nil, err
+	}
+
+	relayIDs := make([]LinkageID, nbProofs-1)
+	for i := 0; i < len(relayIDs); i++ {
+		relayIDs[i] = PlaceholderLinkageID(nbIdVals, bitsPerIdVal)
+	}
+
+	vkeys := make([]plonk.VerifyingKey[FR, G1El, G2El], nbProofs)
+	proofs := make([]plonk.Proof[FR, G1El, G2El], nbProofs)
+	witnesses := make([]plonk.Witness[FR], nbProofs)
+	for i := 0; i < nbProofs; i++ {
+		vkeys[i] = plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccsUnit)
+		proofs[i] = plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit)
+		witnesses[i] = plonk.PlaceholderWitness[FR](ccsUnit)
+	}
+
+	return &KaryRecursiveCircuit[FR, G1El, G2El, GtEl]{
+		BeginID:  PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		RelayIDs: relayIDs,
+		EndID:    PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+
+		SelfFps: selfFps,
+		Count:   count,
+
+		VKeys:     vkeys,
+		Proofs:    proofs,
+		Witnesses: witnesses,
+
+		ValidUnitFps:  unitFpBytes,
+		NbSelfFps:     nbSelfFps,
+		optimizedSize: optimizedSize(ccsUnit, opt),
+	}, nil
+}
+
+func NewKaryRecursiveAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vkeys []plonk.VerifyingKey[FR, G1El, G2El],
+	proofs []plonk.Proof[FR, G1El, G2El],
+	witnesses []plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID LinkageID, relayIDs []LinkageID, endID LinkageID,
+) *KaryRecursiveCircuit[FR, G1El, G2El, GtEl] {
+	return &KaryRecursiveCircuit[FR, G1El, G2El, GtEl]{
+		BeginID:  beginID,
+		RelayIDs: relayIDs,
+		EndID:    endID,
+
+		SelfFps: recursiveFps,
+
+		VKeys:     vkeys,
+		Proofs:    proofs,
+		Witnesses: witnesses,
+	}
+}
+
+// NewKaryRecursiveAssignmentWithCount is the same as NewKaryRecursiveAssignment for a circuit created by
+// NewKaryRecursiveCircuitWithCount, count being the sum of the counts of all the inner proofs.
+func NewKaryRecursiveAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vkeys []plonk.VerifyingKey[FR, G1El, G2El],
+	proofs []plonk.Proof[FR, G1El, G2El],
+	witnesses []plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID LinkageID, relayIDs []LinkageID, endID LinkageID,
+	count uint64,
+) *KaryRecursiveCircuit[FR, G1El, G2El, GtEl] {
+	ret := NewKaryRecursiveAssignment[FR, G1El, G2El, GtEl](
+		vkeys, proofs, witnesses, recursiveFps, beginID, relayIDs, endID)
+	ret.Count = ChainCountOf(count)
+	return ret
+}
@@ -0,0 +1,76 @@
+package chainark
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// same shape as testUnitCircuit2Chain, but another circuit
+type testOtherUnitCircuit2Chain struct {
+	*MultiUnit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]
+}
+
+func (c *testOtherUnitCircuit2Chain) Define(api frontend.API) error {
+	x := api.Mul(c.BeginID.Vals[0], c.EndID.Vals[0])
+	y := api.Add(x, c.BeginID.Vals[1], 11)
+	api.AssertIsDifferent(api.Sub(api.Mul(y, 3), c.EndID.Vals[1]), 0)
+	return c.MultiUnit.Define(api)
+}
+
+func TestKaryRecursive2Chain(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit2Chain(assert, 0)
+	other := newTestUnit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](assert,
+		func(u *MultiUnit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]) frontend.Circuit {
+			return &testOtherUnitCircuit2Chain{MultiUnit: u}
+		}, 1, 0)
+	ids := unit.ids
+
+	type innerProof = testInnerProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine]
+	units := make([]innerProof, 3)
+	for i := 0; i < 3; i++ {
+		units[i] = unit.prove(assert, unit.link(i))
+	}
+	otherProof := other.prove(assert, other.link(1))
+
+	circuit, err := NewKaryRecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, unit.ccs, []common_utils.FingerPrintBytes{unit.fp}, 1, 3)
+	assert.NoError(err)
+
+	assign := func(inner []innerProof, relayIDs []LinkageIDBytes) *KaryRecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT] {
+		vkeys := make([]plonk.VerifyingKey[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine], len(inner))
+		proofs := make([]plonk.Proof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine], len(inner))
+		witnesses := make([]plonk.Witness[sw_bls12377.ScalarField], len(inner))
+		for i := range inner {
+			vkeys[i], proofs[i], witnesses[i] = inner[i].vk, inner[i].proof, inner[i].witness
+		}
+		relays := make([]LinkageID, len(relayIDs))
+		for i := range relayIDs {
+			relays[i] = LinkageIDFromBytes(relayIDs[i], 128)
+		}
+		return NewKaryRecursiveAssignment[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			vkeys, proofs, witnesses,
+			[]common_utils.FingerPrint[sw_bls12377.ScalarField]{unit.recursiveFp()},
+			LinkageIDFromBytes(ids[0], 128), relays, LinkageIDFromBytes(ids[3], 128),
+		)
+	}
+
+	// a chain of 3 unit proofs
+	err = test.IsSolved(circuit, assign(units, []LinkageIDBytes{ids[1], ids[2]}), ecc.BW6_761.ScalarField())
+	assert.NoError(err)
+
+	// a broken relay id
+	err = test.IsSolved(circuit, assign(units, []LinkageIDBytes{ids[1], ids[1]}), ecc.BW6_761.ScalarField())
+	assert.Error(err)
+
+	// a valid proof of another circuit after position 0
+	err = test.IsSolved(circuit, assign([]innerProof{units[0], otherProof, units[2]}, []LinkageIDBytes{ids[1], ids[2]}),
+		ecc.BW6_761.ScalarField())
+	assert.Error(err)
+}
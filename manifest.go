@@ -0,0 +1,261 @@
+package chainark
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * A Manifest pins the circuits of an application: the unit circuits, the recursive circuit and the optional hybrid
+ * circuit, each with its fingerprint and the hashes of its vk and ccs. It is generated after setup, then checked
+ * against the keys loaded to prove or verify, so that keys regenerated or mixed up by mistake are refused instead of
+ * producing proofs that fail to verify later on. The CircuitSet of the manifest has the SelfFps in their order,
+ * {recursiveFp, hybridFp}.
+ */
+
+const ManifestVersion = 1
+
+var ErrManifestMismatch = errors.New("chainark: keys do not match the manifest")
+
+type ManifestCircuit struct {
+	Kind          ProofKind
+	Name          string
+	NbLinks       int // 0 for the recursive circuit
+	FingerPrint   common_utils.FingerPrintBytes
+	VkHash        []byte
+	CcsHash       []byte
+	NbConstraints int
+}
+
+type Manifest struct {
+	NbIDVals     int
+	BitsPerIDVal int
+	NbIDBytes    int               // the byte length of the ids, 0 when they take NbIDVals whole values
+	Count        bool              // the units expose a ChainCount
+	Circuits     []ManifestCircuit // units first, then recursive and hybrid
+}
+
+// NewManifestCircuit describes circuit name, compiled as ccs and set up with vk.
+func NewManifestCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	kind ProofKind, name string, nbLinks int, ccs constraint.ConstraintSystem, vk native_plonk.VerifyingKey,
+) (*ManifestCircuit, error) {
+	fp, err := UnsafeFingerPrintFromVk[FR, G1El, G2El, GtEl](vk)
+	if err != nil {
+		return nil, err
+	}
+	vkHash, err := HashOf(vk)
+	if err != nil {
+		return nil, err
+	}
+	ccsHash, err := HashOf(ccs)
+	if err != nil {
+		return nil, err
+	}
+	return &ManifestCircuit{
+		Kind:          kind,
+		Name:          name,
+		NbLinks:       nbLinks,
+		FingerPrint:   fp,
+		VkHash:        vkHash,
+		CcsHash:       ccsHash,
+		NbConstraints: ccs.GetNbConstraints(),
+	}, nil
+}
+
+// HashOf returns the sha256 of the serialization of v, such as a vk or a ccs, which is also the hash of its file.
+func HashOf(v io.WriterTo) ([]byte, error) {
+	h := sha256.New()
+	_, err := v.WriteTo(h)
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// Set adds c to the manifest, replacing the circuit of the same name if any.
+func (m *Manifest) Set(c ManifestCircuit) {
+	for i := range m.Circuits {
+		if m.Circuits[i].Name == c.Name {
+			m.Circuits[i] = c
+			return
+		}
+	}
+	m.Circuits = append(m.Circuits, c)
+}
+
+func (m *Manifest) Circuit(name string) (*ManifestCircuit, error) {
+	for i := range m.Circuits {
+		if m.Circuits[i].Name == name {
+			return &m.Circuits[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no circuit %v", ErrManifestMismatch, name)
+}
+
+// CircuitSet returns the fingerprints of the manifest, which must have one recursive circuit and at most one hybrid.
+func (m *Manifest) CircuitSet() (*CircuitSet, error) {
+	set := &CircuitSet{NbIDVals: m.NbIDVals, BitsPerIDVal: m.BitsPerIDVal, NbIDBytes: m.NbIDBytes, Count: m.Count}
+	var recursiveFp, hybridFp common_utils.FingerPrintBytes
+	for _, c := range m.Circuits {
+		switch c.Kind {
+		case ProofKindUnit:
+			set.UnitFps = append(set.UnitFps, c.FingerPrint)
+		case ProofKindRecursive:
+			if recursiveFp != nil {
+				return nil, fmt.Errorf("%w: several recursive circuits", ErrManifestMismatch)
+			}
+			recursiveFp = c.FingerPrint
+		case ProofKindHybrid:
+			if hybridFp != nil {
+				return nil, fmt.Errorf("%w: several hybrid circuits", ErrManifestMismatch)
+			}
+			hybridFp = c.FingerPrint
+		}
+	}
+	if recursiveFp == nil {
+		return nil, fmt.Errorf("%w: no recursive circuit", ErrManifestMismatch)
+	}
+	set.SelfFps = []common_utils.FingerPrintBytes{recursiveFp}
+	if hybridFp != nil {
+		set.SelfFps = append(set.SelfFps, hybridFp)
+	}
+	return set, nil
+}
+
+// CheckVk checks vk against the vk hash of circuit name.
+func (m *Manifest) CheckVk(name string, vk native_plonk.VerifyingKey) error {
+	c, err := m.Circuit(name)
+	if err != nil {
+		return err
+	}
+	h, err := HashOf(vk)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(h, c.VkHash) {
+		return fmt.Errorf("%w: vk of %v", ErrManifestMismatch, name)
+	}
+	return nil
+}
+
+// CheckCcs checks ccs against the ccs hash and the number of constraints of circuit name.
+func (m *Manifest) CheckCcs(name string, ccs constraint.ConstraintSystem) error {
+	c, err := m.Circuit(name)
+	if err != nil {
+		return err
+	}
+	if ccs.GetNbConstraints() != c.NbConstraints {
+		return fmt.Errorf("%w: ccs of %v has %v constraints, expecting %v", ErrManifestMismatch,
+			name, ccs.GetNbConstraints(), c.NbConstraints)
+	}
+	h, err := HashOf(ccs)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(h, c.CcsHash) {
+		return fmt.Errorf("%w: ccs of %v", ErrManifestMismatch, name)
+	}
+	return nil
+}
+
+type manifestCircuitJSON struct {
+	Kind          ProofKind `json:"kind"`
+	Name          string    `json:"name"`
+	NbLinks       int       `json:"nbLinks,omitempty"`
+	FingerPrint   string    `json:"fingerPrint"`
+	VkHash        string    `json:"vkHash"`
+	CcsHash       string    `json:"ccsHash"`
+	NbConstraints int       `json:"nbConstraints"`
+}
+
+type manifestJSON struct {
+	Version      int                   `json:"version"`
+	NbIDVals     int                   `json:"nbIdVals"`
+	BitsPerIDVal int                   `json:"bitsPerIdVal"`
+	NbIDBytes    int                   `json:"nbIdBytes,omitempty"`
+	Count        bool                  `json:"count,omitempty"`
+	Circuits     []manifestCircuitJSON `json:"circuits"`
+}
+
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	v := manifestJSON{
+		Version:      ManifestVersion,
+		NbIDVals:     m.NbIDVals,
+		BitsPerIDVal: m.BitsPerIDVal,
+		NbIDBytes:    m.NbIDBytes,
+		Count:        m.Count,
+		Circuits:     make([]manifestCircuitJSON, len(m.Circuits)),
+	}
+	for i, c := range m.Circuits {
+		v.Circuits[i] = manifestCircuitJSON{
+			Kind:          c.Kind,
+			Name:          c.Name,
+			NbLinks:       c.NbLinks,
+			FingerPrint:   hex.EncodeToString(c.FingerPrint),
+			VkHash:        hex.EncodeToString(c.VkHash),
+			CcsHash:       hex.EncodeToString(c.CcsHash),
+			NbConstraints: c.NbConstraints,
+		}
+	}
+	return json.Marshal(v)
+}
+
+func (m *Manifest) UnmarshalJSON(data []byte) error {
+	var v manifestJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	if v.Version != ManifestVersion {
+		return fmt.Errorf("%w: manifest version %v", ErrManifestMismatch, v.Version)
+	}
+
+	ret := Manifest{NbIDVals: v.NbIDVals, BitsPerIDVal: v.BitsPerIDVal, NbIDBytes: v.NbIDBytes, Count: v.Count}
+	names := make(map[string]bool)
+	for _, c := range v.Circuits {
+		switch c.Kind {
+		case ProofKindUnit, ProofKindRecursive, ProofKindHybrid:
+		default:
+			return fmt.Errorf("%w: circuit %v of kind %q", ErrManifestMismatch, c.Name, c.Kind)
+		}
+		if c.Name == "" || names[c.Name] {
+			return fmt.Errorf("%w: circuit name %q", ErrManifestMismatch, c.Name)
+		}
+		names[c.Name] = true
+
+		fp, err := hex.DecodeString(c.FingerPrint)
+		if err != nil {
+			return fmt.Errorf("%w: fingerprint of %v: %v", ErrManifestMismatch, c.Name, err)
+		}
+		vkHash, err := hex.DecodeString(c.VkHash)
+		if err != nil {
+			return fmt.Errorf("%w: vk hash of %v: %v", ErrManifestMismatch, c.Name, err)
+		}
+		ccsHash, err := hex.DecodeString(c.CcsHash)
+		if err != nil {
+			return fmt.Errorf("%w: ccs hash of %v: %v", ErrManifestMismatch, c.Name, err)
+		}
+		ret.Circuits = append(ret.Circuits, ManifestCircuit{
+			Kind:          c.Kind,
+			Name:          c.Name,
+			NbLinks:       c.NbLinks,
+			FingerPrint:   fp,
+			VkHash:        vkHash,
+			CcsHash:       ccsHash,
+			NbConstraints: c.NbConstraints,
+		})
+	}
+	*m = ret
+	return nil
+}
@@ -0,0 +1,92 @@
+package chainark
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gnark/test/unsafekzg"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+func TestManifest(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// two circuits, standing for a unit and the recursive one
+	var ccss []constraint.ConstraintSystem
+	var vks []native_plonk.VerifyingKey
+	for _, nbSelfFps := range []int{1, 2} {
+		circuit := &testUnitCircuit{
+			MultiUnit: NewMultiUnitCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](2, 128, nbSelfFps),
+		}
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit)
+		assert.NoError(err)
+		srs, srsLagrange, err := unsafekzg.NewSRS(ccs, unsafekzg.WithFSCache())
+		assert.NoError(err)
+		_, vk, err := native_plonk.Setup(ccs, srs, srsLagrange)
+		assert.NoError(err)
+		ccss = append(ccss, ccs)
+		vks = append(vks, vk)
+	}
+
+	m := &Manifest{NbIDVals: 2, BitsPerIDVal: 128, Count: true}
+	for i, kind := range []ProofKind{ProofKindUnit, ProofKindRecursive} {
+		c, err := NewManifestCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			kind, string(kind), 4*(1-i), ccss[i], vks[i])
+		assert.NoError(err)
+		assert.Equal(ccss[i].GetNbConstraints(), c.NbConstraints)
+		m.Set(*c)
+	}
+
+	data, err := json.Marshal(m)
+	assert.NoError(err)
+	var loaded Manifest
+	assert.NoError(json.Unmarshal(data, &loaded))
+	assert.Equal(*m, loaded)
+
+	assert.NoError(loaded.CheckVk("unit", vks[0]))
+	assert.NoError(loaded.CheckVk("recursive", vks[1]))
+	assert.True(errors.Is(loaded.CheckVk("unit", vks[1]), ErrManifestMismatch))
+	assert.True(errors.Is(loaded.CheckVk("hybrid", vks[1]), ErrManifestMismatch))
+	assert.NoError(loaded.CheckCcs("unit", ccss[0]))
+	assert.True(errors.Is(loaded.CheckCcs("recursive", ccss[0]), ErrManifestMismatch))
+
+	set, err := loaded.CircuitSet()
+	assert.NoError(err)
+	recursive, err := loaded.Circuit("recursive")
+	assert.NoError(err)
+	assert.Equal([]common_utils.FingerPrintBytes{recursive.FingerPrint}, set.SelfFps)
+	assert.Equal(1, len(set.UnitFps))
+	assert.True(set.Count)
+
+	// set replaces by name
+	c := loaded.Circuits[0]
+	c.NbLinks = 8
+	loaded.Set(c)
+	assert.Equal(2, len(loaded.Circuits))
+	assert.Equal(8, loaded.Circuits[0].NbLinks)
+
+	// a second recursive or hybrid circuit is never dropped silently
+	for _, kind := range []ProofKind{ProofKindRecursive, ProofKindHybrid} {
+		duplicated := loaded
+		duplicated.Circuits = append([]ManifestCircuit{}, loaded.Circuits...)
+		for i := 0; i < 2; i++ {
+			duplicated.Circuits = append(duplicated.Circuits, ManifestCircuit{Kind: kind, Name: fmt.Sprintf("%v%v", kind, i),
+				FingerPrint: recursive.FingerPrint})
+		}
+		_, err = duplicated.CircuitSet()
+		assert.True(errors.Is(err, ErrManifestMismatch))
+	}
+
+	loaded.Circuits = loaded.Circuits[:1]
+	_, err = loaded.CircuitSet()
+	assert.True(errors.Is(err, ErrManifestMismatch))
+}
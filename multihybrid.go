@@ -0,0 +1,223 @@
+package chainark
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// MultiHybridCircuit generalizes HybridCircuit to any number of UnitCore components verified in circuit after the
+// inner proof, chaining BeginID -> RelayIDs[0] -> ... -> RelayIDs[n-1] -> EndID, where the inner proof links BeginID
+// to RelayIDs[0] and Comps[i] links the next two ids. In the prepend mode, Comps[0] links BeginID to RelayIDs[0]
+// before the inner proof, which links RelayIDs[0] to RelayIDs[1] if there are more components, or to EndID otherwise.
+type MultiHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	BeginID  LinkageID `gnark:",public"`
+	RelayIDs []LinkageID
+	EndID    LinkageID `gnark:",public"`
+
+	SelfFps []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count   ChainCount                     `gnark:",public"` // optional, enabled iff the unit circuits have one
+
+	VKey    plonk.VerifyingKey[FR, G1El, G2El]
+	Proof   plonk.Proof[FR, G1El, G2El]
+	Witness plonk.Witness[FR]
+
+	Comps []UnitCore[FR, G1El, G2El, GtEl]
+
+	// constant values passed from outside
+	ValidUnitFps []common_utils.FingerPrintBytes
+	NbSelfFps    int
+	Padding      Padding // see NewPadding
+
+	prepend bool
+}
+
+func (c *MultiHybridCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginID.Vals[0])
+	if err != nil {
+		return err
+	}
+
+	nbComps := len(c.Comps)
+
+	ids := make([]LinkageID, 0, nbComps+2)
+	ids = append(ids, c.BeginID)
+	ids = append(ids, c.RelayIDs...)
+	ids = append(ids, c.EndID)
+
+	// the inner proof is the step from ids[proofStep] to ids[proofStep+1], the components take the other steps
+	proofStep := 0
+	if c.prepend {
+		proofStep = 1
+	}
+
+	// verify the vkey
+	rp := recursiveProof[FR, G1El, G2El, GtEl]{
+		beginID:   ids[proofStep],
+		endID:     ids[proofStep+1],
+		nbSelfFps: c.NbSelfFps,
+	}
+	err = rp.assertRelations(api, c.VKey, c.Witness, c.SelfFps, c.ValidUnitFps)
+	if err != nil {
+		return err
+	}
+
+	assertIds[FR](api, ids[proofStep], ids[proofStep+1], c.Witness.Public)
+
+	if c.Count.Enabled() {
+		nbLinks := 0
+		for i := 0; i < nbComps; i++ {
+			n, err := getNbLinks(c.Comps[i])
+			if err != nil {
+				return err
+			}
+			nbLinks += n
+		}
+		assertCountSum[FR](api, c.Count, len(c.BeginID.Vals), c.NbSelfFps, nbLinks, c.Witness.Public)
+	}
+
+	verifier, err := plonk.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return err
+	}
+
+	err = verifier.AssertProof(c.VKey, c.Proof, c.Witness, plonk.WithCompleteArithmetic())
+	if err != nil {
+		return err
+	}
+
+	// linking the ids of the other steps by the components
+	for i := 0; i < nbComps; i++ {
+		step := i
+		if step >= proofStep {
+			step++
+		}
+		err = ids[step].AssertIsEqual(api, c.Comps[i].GetBeginID())
+		if err != nil {
+			return err
+		}
+		err = ids[step+1].AssertIsEqual(api, c.Comps[i].GetEndID())
+		if err != nil {
+			return err
+		}
+		err = c.Comps[i].Define(api)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func NewMultiHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComps []UnitCore[FR, G1El, G2El, GtEl],
+	prepend bool,
+) (*MultiHybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newMultiHybridCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, extraComps, prepend, false)
+}
+
+// NewMultiHybridCircuitWithCount is the same as NewMultiHybridCircuit for unit circuits exposing a ChainCount, see
+// NewMultiUnitCircuitWithCount.
+func NewMultiHybridCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComps []UnitCore[FR, G1El, G2El, GtEl],
+	prepend bool,
+) (*MultiHybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newMultiHybridCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, extraComps, prepend, true)
+}
+
+func newMultiHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComps []UnitCore[FR, G1El, G2El, GtEl],
+	prepend bool,
+	counted bool,
+) (*MultiHybridCircuit[FR, G1El, G2El, GtEl], error) {
+
+	if nbSelfFps <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
+	}
+	if len(extraComps) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrBadCompCount, len(extraComps))
+	}
+	selfFps := make([]common_utils.FingerPrint[FR], nbSelfFps)
+	count, err := unitCount(ccsUnit.GetNbPublicVariables(), nbIdVals, nbSelfFps, counted)
+	if err != nil {
+		return nil, err
+	}
+
+	relayIDs := make([]LinkageID, len(extraComps))
+	for i := 0; i < len(relayIDs); i++ {
+		relayIDs[i] = PlaceholderLinkageID(nbIdVals, bitsPerIdVal)
+	}
+
+	return &MultiHybridCircuit[FR, G1El, G2El, GtEl]{
+		BeginID:  PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		RelayIDs: relayIDs,
+		EndID:    PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+
+		SelfFps: selfFps,
+		Count:   count,
+
+		VKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccsUnit),
+		Proof:   plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit),
+		Witness: plonk.PlaceholderWitness[FR](ccsUnit),
+
+		Comps: extraComps,
+
+		ValidUnitFps: unitFpBytes,
+		NbSelfFps:    nbSelfFps,
+		prepend:      prepend,
+	}, nil
+}
+
+func NewMultiHybridAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vkey plonk.VerifyingKey[FR, G1El, G2El],
+	proof plonk.Proof[FR, G1El, G2El],
+	witness plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID LinkageID, relayIDs []LinkageID, endID LinkageID,
+	extraComps []UnitCore[FR, G1El, G2El, GtEl],
+) *MultiHybridCircuit[FR, G1El, G2El, GtEl] {
+	return &MultiHybridCircuit[FR, G1El, G2El, GtEl]{
+		BeginID:  beginID,
+		RelayIDs: relayIDs,
+		EndID:    endID,
+
+		SelfFps: recursiveFps,
+
+		VKey:    vkey,
+		Proof:   proof,
+		Witness: witness,
+
+		Comps: extraComps,
+	}
+}
+
+// NewMultiHybridAssignmentWithCount is the same as NewMultiHybridAssignment for a circuit created by
+// NewMultiHybridCircuitWithCount, count being the count of the inner proof plus the links of all the extraComps.
+func NewMultiHybridAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vkey plonk.VerifyingKey[FR, G1El, G2El],
+	proof plonk.Proof[FR, G1El, G2El],
+	witness plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID LinkageID, relayIDs []LinkageID, endID LinkageID,
+	extraComps []UnitCore[FR, G1El, G2El, GtEl],
+	count uint64,
+) *MultiHybridCircuit[FR, G1El, G2El, GtEl] {
+	ret := NewMultiHybridAssignment[FR, G1El, G2El, GtEl](
+		vkey, proof, witness, recursiveFps, beginID, relayIDs, endID, extraComps)
+	ret.Count = ChainCountOf(count)
+	return ret
+}
@@ -0,0 +1,82 @@
+package chainark
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// the relation of testUnitCircuit2Chain, verified in circuit by a hybrid circuit
+type testComp2Chain struct {
+	BeginID LinkageID
+	EndID   LinkageID
+}
+
+func (c *testComp2Chain) Define(api frontend.API) error {
+	x := api.Mul(c.BeginID.Vals[0], c.EndID.Vals[0])
+	y := api.Add(x, c.BeginID.Vals[1], 7)
+	api.AssertIsDifferent(api.Sub(api.Mul(y, 3), c.EndID.Vals[1]), 0)
+	return nil
+}
+
+func (c *testComp2Chain) GetBeginID() LinkageID {
+	return c.BeginID
+}
+
+func (c *testComp2Chain) GetEndID() LinkageID {
+	return c.EndID
+}
+
+func TestMultiHybrid2Chain(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit2Chain(assert, 0)
+	ids := unit.ids
+
+	comps := func(n int) []UnitCore[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT] {
+		ret := make([]UnitCore[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT], n)
+		for i := 0; i < n; i++ {
+			ret[i] = &testComp2Chain{BeginID: PlaceholderLinkageID(2, 128), EndID: PlaceholderLinkageID(2, 128)}
+		}
+		return ret
+	}
+
+	// the unit proof links ids[proofStep] to ids[proofStep+1], 2 components link the other ids
+	for _, proofStep := range []int{0, 1} {
+		inner := unit.prove(assert, unit.link(proofStep))
+
+		circuit, err := NewMultiHybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			2, 128, unit.ccs, []common_utils.FingerPrintBytes{unit.fp}, 1, comps(2), proofStep == 1)
+		assert.NoError(err)
+
+		assign := func(relayID LinkageIDBytes) *MultiHybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT] {
+			linkageIDs := []LinkageID{LinkageIDFromBytes(ids[0], 128), LinkageIDFromBytes(relayID, 128),
+				LinkageIDFromBytes(ids[2], 128), LinkageIDFromBytes(ids[3], 128)}
+			extraComps := comps(0)
+			for i := 0; i < 3; i++ {
+				if i != proofStep {
+					extraComps = append(extraComps, &testComp2Chain{BeginID: linkageIDs[i], EndID: linkageIDs[i+1]})
+				}
+			}
+			return NewMultiHybridAssignment[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+				inner.vk, inner.proof, inner.witness,
+				[]common_utils.FingerPrint[sw_bls12377.ScalarField]{unit.recursiveFp()},
+				linkageIDs[0], linkageIDs[1:3], linkageIDs[3], extraComps)
+		}
+
+		err = test.IsSolved(circuit, assign(ids[1]), ecc.BW6_761.ScalarField())
+		assert.NoError(err)
+
+		// the components and the proof must be chained
+		err = test.IsSolved(circuit, assign(ids[3]), ecc.BW6_761.ScalarField())
+		assert.Error(err)
+	}
+
+	_, err := NewMultiHybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, unit.ccs, []common_utils.FingerPrintBytes{unit.fp}, 1, nil, false)
+	assert.True(errors.Is(err, ErrBadCompCount))
+}
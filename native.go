@@ -0,0 +1,210 @@
+package chainark
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * VerifyChainProof verifies natively a proof generated by a Recursive or Hybrid circuit, same as what the Verifier
+ * circuit does in circuit:
+ * 1. the proof is valid against vk and pubWitness;
+ * 2. the fingerprint of vk is one of expectedFps;
+ * 3. the SelfFps found in pubWitness are exactly expectedFps, in the same order;
+ * 4. the begin and end IDs found in pubWitness are beginID and endID.
+ * The public witness is expected to follow the layout of BeginID, EndID, SelfFps and then the Count iff counted. The
+ * ids could have a partial top value, see LinkageIDFromBytes.
+ */
+func VerifyChainProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vk native_plonk.VerifyingKey, proof native_plonk.Proof, pubWitness witness.Witness,
+	expectedFps []common_utils.FingerPrintBytes,
+	beginID, endID LinkageIDBytes, bitsPerIdVal int, counted bool,
+) error {
+	if len(beginID) != len(endID) || len(beginID) == 0 {
+		return fmt.Errorf("%w: unexpected begin or end id length", ErrWitnessShape)
+	}
+	decoded, err := DecodeChainWitness[FR](pubWitness, len(beginID), bitsPerIdVal, len(expectedFps), counted)
+	if err != nil {
+		return err
+	}
+
+	var fr FR
+	err = native_plonk.Verify(proof, vk, pubWitness,
+		plonk.GetNativeVerifierOptions(outerField[FR](), fr.Modulus()))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+
+	vkFp, err := UnsafeFingerPrintFromVk[FR, G1El, G2El, GtEl](vk)
+	if err != nil {
+		return err
+	}
+	found := false
+	for i := 0; i < len(expectedFps); i++ {
+		if fpBytesEqual(vkFp, expectedFps[i]) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrUnknownVkFp
+	}
+
+	for i := 0; i < len(expectedFps); i++ {
+		if !fpBytesEqual(decoded.SelfFps[i], expectedFps[i]) {
+			return fmt.Errorf("%w: at index %v", ErrSelfFpsMismatch, i)
+		}
+	}
+
+	if !bytes.Equal(decoded.BeginID, beginID) {
+		return ErrBeginIDMismatch
+	}
+	if !bytes.Equal(decoded.EndID, endID) {
+		return ErrEndIDMismatch
+	}
+
+	return nil
+}
+
+// ChainWitness holds the values decoded from the public witness of a Unit, Recursive or Hybrid proof.
+type ChainWitness struct {
+	BeginID  LinkageIDBytes
+	EndID    LinkageIDBytes
+	SelfFps  []common_utils.FingerPrintBytes
+	Count    uint64
+	HasCount bool
+}
+
+/**
+ * DecodeChainWitness is the native inverse of LinkageIDFromBytes and FingerPrintFromBytes on a public witness laid
+ * out as BeginID, EndID, SelfFps (PlaceHolderFps for a unit proof) and then the Count iff counted, as the circuits of
+ * units created by NewMultiUnitCircuitWithCount. The ids of nbIdBytes bytes are laid out as by LinkageIDFromBytes, and
+ * decoded to their original bytes, including those with a partial top value.
+ */
+func DecodeChainWitness[FR emulated.FieldParams](
+	w witness.Witness, nbIdBytes, bitsPerIdVal, nbSelfFps int, counted bool,
+) (*ChainWitness, error) {
+	if nbIdBytes <= 0 || bitsPerIdVal <= 0 {
+		return nil, fmt.Errorf("%w: ids of %v bytes on %v-bit values", ErrWitnessShape, nbIdBytes, bitsPerIdVal)
+	}
+	values, err := witnessValues(w)
+	if err != nil {
+		return nil, err
+	}
+
+	nbIdVals := len(idLayout(nbIdBytes, bitsPerIdVal))
+	expectedLen := 2*nbIdVals + nbSelfFps
+	if counted {
+		expectedLen++
+	}
+	if len(values) != expectedLen {
+		return nil, fmt.Errorf("%w: %v public values, expecting %v", ErrWitnessShape, len(values), expectedLen)
+	}
+
+	begin, err := joinIDValues(values[:nbIdVals], nbIdBytes, bitsPerIdVal)
+	if err != nil {
+		return nil, err
+	}
+	end, err := joinIDValues(values[nbIdVals:2*nbIdVals], nbIdBytes, bitsPerIdVal)
+	if err != nil {
+		return nil, err
+	}
+
+	var fr FR
+	bytesPerFp := (fr.Modulus().BitLen() + 7) / 8
+	fps := make([]common_utils.FingerPrintBytes, nbSelfFps)
+	for i := 0; i < nbSelfFps; i++ {
+		fp, err := valuesToBytes(values[2*nbIdVals+i:2*nbIdVals+i+1], bytesPerFp)
+		if err != nil {
+			return nil, err
+		}
+		fps[i] = common_utils.FingerPrintBytes(fp)
+	}
+
+	ret := &ChainWitness{
+		BeginID: begin,
+		EndID:   end,
+		SelfFps: fps,
+	}
+	if counted {
+		count := values[expectedLen-1]
+		if count.BitLen() > nbCountBits {
+			return nil, fmt.Errorf("%w: count out of range", ErrWitnessShape)
+		}
+		ret.Count = count.Uint64()
+		ret.HasCount = true
+	}
+
+	return ret, nil
+}
+
+func valuesToBytes(values []*big.Int, bytesPerVal int) ([]byte, error) {
+	ret := make([]byte, len(values)*bytesPerVal)
+	for i := 0; i < len(values); i++ {
+		if (values[i].BitLen()+7)/8 > bytesPerVal {
+			return nil, fmt.Errorf("%w: value exceeds %v bytes", ErrWitnessShape, bytesPerVal)
+		}
+		values[i].FillBytes(ret[i*bytesPerVal : (i+1)*bytesPerVal])
+	}
+	return ret, nil
+}
+
+func fpBytesEqual(a, b common_utils.FingerPrintBytes) bool {
+	return new(big.Int).SetBytes(a).Cmp(new(big.Int).SetBytes(b)) == 0
+}
+
+type bigIntElement[T any] interface {
+	*T
+	BigInt(res *big.Int) *big.Int
+}
+
+func elementsToBigInts[T any, PT bigIntElement[T]](vec []T) []*big.Int {
+	ret := make([]*big.Int, len(vec))
+	for i := 0; i < len(vec); i++ {
+		ret[i] = PT(&vec[i]).BigInt(new(big.Int))
+	}
+	return ret
+}
+
+func witnessValues(w witness.Witness) ([]*big.Int, error) {
+	switch vec := w.Vector().(type) {
+	case fr_bn254.Vector:
+		return elementsToBigInts[fr_bn254.Element](vec), nil
+	case fr_bls12381.Vector:
+		return elementsToBigInts[fr_bls12381.Element](vec), nil
+	case fr_bls12377.Vector:
+		return elementsToBigInts[fr_bls12377.Element](vec), nil
+	case fr_bw6761.Vector:
+		return elementsToBigInts[fr_bw6761.Element](vec), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported witness vector type %T", ErrWitnessShape, vec)
+	}
+}
+
+func witnessCurve(w witness.Witness) (ecc.ID, error) {
+	switch vec := w.Vector().(type) {
+	case fr_bn254.Vector:
+		return ecc.BN254, nil
+	case fr_bls12381.Vector:
+		return ecc.BLS12_381, nil
+	case fr_bls12377.Vector:
+		return ecc.BLS12_377, nil
+	case fr_bw6761.Vector:
+		return ecc.BW6_761, nil
+	default:
+		return ecc.UNKNOWN, fmt.Errorf("%w: unsupported witness vector type %T", ErrWitnessShape, vec)
+	}
+}
@@ -0,0 +1,115 @@
+package chainark
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// a tiny unit circuit, with all kinds of gates so that none of the selector commitments is zero
+type testUnitCircuit struct {
+	*MultiUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+}
+
+func (c *testUnitCircuit) Define(api frontend.API) error {
+	x := api.Mul(c.BeginID.Vals[0], c.EndID.Vals[0])
+	y := api.Add(x, c.BeginID.Vals[1], 7)
+	api.AssertIsDifferent(api.Sub(api.Mul(y, 3), c.EndID.Vals[1]), 0)
+	return c.MultiUnit.Define(api)
+}
+
+func TestVerifyChainProof(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit(assert, 1, 0)
+	vk, fps := unit.vk, []common_utils.FingerPrintBytes{unit.fp}
+	beginID, endID := unit.ids[0], unit.ids[1]
+
+	// a unit proof carrying its own fingerprint looks exactly like a recursive proof
+	proof, pubWitness := unit.proveNative(assert, unit.link(0, unit.fp))
+
+	verify := func(fps []common_utils.FingerPrintBytes, begin, end LinkageIDBytes) error {
+		return VerifyChainProof[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			vk, proof, pubWitness, fps, begin, end, 128, false)
+	}
+
+	assert.NoError(verify(fps, beginID, endID))
+	assert.True(errors.Is(verify(fps, endID, endID), ErrBeginIDMismatch))
+	assert.True(errors.Is(verify(fps, beginID, beginID), ErrEndIDMismatch))
+	assert.True(errors.Is(verify([]common_utils.FingerPrintBytes{GetPlaceholderFp()}, beginID, endID), ErrUnknownVkFp))
+	assert.True(errors.Is(verify([]common_utils.FingerPrintBytes{unit.fp, unit.fp}, beginID, endID), ErrWitnessShape))
+	assert.True(errors.Is(verify(fps, beginID[:16], endID[:16]), ErrWitnessShape))
+
+	// the witness of another proof
+	otherProof, otherPubWitness := unit.proveNative(assert, unit.link(0))
+	err := VerifyChainProof[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		vk, proof, otherPubWitness, fps, beginID, endID, 128, false)
+	assert.True(errors.Is(err, ErrInvalidProof))
+
+	// a valid proof of a known vkey, but carrying the placeholder instead of the expected SelfFps
+	err = VerifyChainProof[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		vk, otherProof, otherPubWitness, fps, beginID, endID, 128, false)
+	assert.True(errors.Is(err, ErrSelfFpsMismatch))
+}
+
+func TestDecodeChainWitness(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	ids := testIDs(assert)
+	beginID, endID := ids[0], ids[1]
+
+	assignment := NewMultiUnitAssignmentWithCount[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		beginID, endID, 128, 2, 8)
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	assert.NoError(err)
+
+	decoded, err := DecodeChainWitness[sw_bn254.ScalarField](witness, 32, 128, 2, true)
+	assert.NoError(err)
+	assert.Equal(beginID, decoded.BeginID)
+	assert.Equal(endID, decoded.EndID)
+	assert.Equal(2, len(decoded.SelfFps))
+	for i := 0; i < len(decoded.SelfFps); i++ {
+		assert.Equal(GetPlaceholderFp(), decoded.SelfFps[i])
+	}
+	assert.True(decoded.HasCount)
+	assert.Equal(uint64(8), decoded.Count)
+
+	_, err = DecodeChainWitness[sw_bn254.ScalarField](witness, 32, 128, 4, true)
+	assert.True(errors.Is(err, ErrWitnessShape))
+
+	// the count is never guessed from the length of the witness
+	_, err = DecodeChainWitness[sw_bn254.ScalarField](witness, 32, 128, 2, false)
+	assert.True(errors.Is(err, ErrWitnessShape))
+}
+
+func TestDecodeChainWitnessPartialTopValue(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// 20-byte addresses on 128-bit values, with a top value of 32 bits
+	ids := testIDs(assert)
+	beginID, endID := ids[0][:20], ids[1][:20]
+
+	for _, bitsPerIdVal := range []int{128, 60, 20} {
+		assignment := NewMultiUnitAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			beginID, endID, bitsPerIdVal, 1)
+		witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+		assert.NoError(err)
+
+		decoded, err := DecodeChainWitness[sw_bn254.ScalarField](witness, len(beginID), bitsPerIdVal, 1, false)
+		assert.NoError(err)
+		assert.Equal(beginID, decoded.BeginID)
+		assert.Equal(endID, decoded.EndID)
+	}
+
+	// the top value of 20-byte ids does not fit in the one of 17-byte ids
+	assignment := NewMultiUnitAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		beginID, endID, 128, 1)
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	assert.NoError(err)
+	_, err = DecodeChainWitness[sw_bn254.ScalarField](witness, 17, 128, 1, false)
+	assert.True(errors.Is(err, ErrWitnessShape))
+}
@@ -0,0 +1,157 @@
+package chainark
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+)
+
+/**
+ * With the optimization turned on, the inner proofs of a circuit are verified by AssertDifferentProofs, sharing the
+ * BaseVerifyingKey of the first vkey and batching their KZG openings. This only holds if all the circuits whose proofs
+ * could be verified, units, recursive and hybrid circuits alike, have the same domain size, the prerequisite of the
+ * optimization. It is asserted on each vkey against the domain size of the circuit given to the constructor, so that
+ * the Size of the vkeys is then a constant, saving its binary decomposition.
+ */
+
+// DomainSize returns the size of the PLONK evaluation domain of ccs, i.e. the Size of its verifying key.
+func DomainSize(ccs constraint.ConstraintSystem) uint64 {
+	return ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints() + ccs.GetNbPublicVariables()))
+}
+
+// assertProofs verifies the proofs, with the optimization if size is not 0.
+func assertProofs[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	api frontend.API, size uint64,
+	vkeys []plonk.VerifyingKey[FR, G1El, G2El],
+	proofs []plonk.Proof[FR, G1El, G2El],
+	witnesses []plonk.Witness[FR],
+) error {
+	verifier, err := plonk.NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return err
+	}
+
+	if size == 0 {
+		for i := 0; i < len(proofs); i++ {
+			err = verifier.AssertProof(vkeys[i], proofs[i], witnesses[i], plonk.WithCompleteArithmetic())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	circuitVks := make([]plonk.CircuitVerifyingKey[FR, G1El], len(vkeys))
+	switches := make([]frontend.Variable, len(vkeys))
+	for i := 0; i < len(vkeys); i++ {
+		api.AssertIsEqual(vkeys[i].Size, size)
+		circuitVks[i] = vkeys[i].CircuitVerifyingKey
+		circuitVks[i].Size = size
+		switches[i] = i
+	}
+	return verifier.AssertDifferentProofs(vkeys[0].BaseVerifyingKey,
+		circuitVks, switches, proofs, witnesses,
+		plonk.WithCompleteArithmetic(),
+	)
+}
+
+// optimizedSize returns the size passed to assertProofs by a circuit built upon ccs.
+func optimizedSize(ccs constraint.ConstraintSystem, opt []bool) uint64 {
+	if len(opt) == 0 || !opt[0] {
+		return 0
+	}
+	return DomainSize(ccs)
+}
+
+// CircuitDomain is the PLONK domain of a circuit taking part in the optimization.
+type CircuitDomain struct {
+	Name       string
+	NbRows     int // the number of constraints, plus one per public input
+	DomainSize uint64
+}
+
+// Padding returns the number of constraints to add to the circuit for its domain to be of the given size.
+func (d CircuitDomain) Padding(size uint64) (int, error) {
+	if d.DomainSize > size {
+		return 0, fmt.Errorf("%w: %v has a domain of size %v, more than %v", ErrDomainSize, d.Name, d.DomainSize, size)
+	}
+	if d.DomainSize == size {
+		return 0, nil
+	}
+	return int(size/2) + 1 - d.NbRows, nil
+}
+
+// OptimizationReport tells whether the optimization is sound for a set of circuits, see CheckOptimization.
+type OptimizationReport struct {
+	Circuits   []CircuitDomain // sorted by name
+	DomainSize uint64          // the largest domain size, the one all the circuits should have
+}
+
+// Sound tells whether all the circuits have the same domain size.
+func (r *OptimizationReport) Sound() bool {
+	for _, c := range r.Circuits {
+		if c.DomainSize != r.DomainSize {
+			return false
+		}
+	}
+	return true
+}
+
+// Paddings returns the number of constraints to add to each circuit, in the order of Circuits, for all of them to have
+// a domain of the given size, DomainSize if size is 0.
+func (r *OptimizationReport) Paddings(size uint64) ([]int, error) {
+	if size == 0 {
+		size = r.DomainSize
+	}
+	ret := make([]int, len(r.Circuits))
+	for i, c := range r.Circuits {
+		padding, err := c.Padding(size)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = padding
+	}
+	return ret, nil
+}
+
+/**
+ * CheckOptimization reports the domain sizes of the compiled circuits, by name, that are to be created with the
+ * optimization, i.e. the unit circuits and the recursive and hybrid circuits verifying their proofs. If the domain
+ * sizes differ, the optimization is not sound and the returned error, wrapping ErrDomainSize, lists the number of
+ * constraints to add to the smaller circuits, so that setup could fail before any key is written.
+ */
+func CheckOptimization(ccss map[string]constraint.ConstraintSystem) (*OptimizationReport, error) {
+	r := &OptimizationReport{}
+	for name, ccs := range ccss {
+		c := CircuitDomain{
+			Name:       name,
+			NbRows:     ccs.GetNbConstraints() + ccs.GetNbPublicVariables(),
+			DomainSize: DomainSize(ccs),
+		}
+		r.Circuits = append(r.Circuits, c)
+		r.DomainSize = max(r.DomainSize, c.DomainSize)
+	}
+	sort.Slice(r.Circuits, func(i, j int) bool { return r.Circuits[i].Name < r.Circuits[j].Name })
+
+	if r.Sound() {
+		return r, nil
+	}
+	paddings, err := r.Paddings(0)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for i, c := range r.Circuits {
+		if paddings[i] != 0 {
+			missing = append(missing, fmt.Sprintf("%v needs %v more constraints", c.Name, paddings[i]))
+		}
+	}
+	return r, fmt.Errorf("%w: domain size %v expected, %v", ErrDomainSize, r.DomainSize, strings.Join(missing, ", "))
+}
@@ -0,0 +1,119 @@
+package chainark
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	plonk_bls12377 "github.com/consensys/gnark/backend/plonk/bls12-377"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// same as testUnitCircuit2Chain, with NbExtra more constraints
+type testPaddedUnit2Chain struct {
+	*testUnitCircuit2Chain
+	NbExtra int
+}
+
+func (c *testPaddedUnit2Chain) Define(api frontend.API) error {
+	x := c.BeginID.Vals[0]
+	for i := 0; i < c.NbExtra; i++ {
+		x = api.Mul(x, x)
+	}
+	return c.testUnitCircuit2Chain.Define(api)
+}
+
+func TestHybridOptimization2Chain(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	unit := setupTestUnit2Chain(assert, 0)
+	ids, ccs, fp := unit.ids, unit.ccs, unit.fp
+	assert.Equal(unit.vk.(*plonk_bls12377.VerifyingKey).Size, DomainSize(ccs))
+
+	// a unit circuit in the next size range
+	padded, err := frontend.Compile(ecc.BLS12_377.ScalarField(), scs.NewBuilder, &testPaddedUnit2Chain{
+		testUnitCircuit2Chain: &testUnitCircuit2Chain{
+			MultiUnit: NewMultiUnitCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](2, 128, 1),
+		},
+		NbExtra: int(DomainSize(ccs)),
+	})
+	assert.NoError(err)
+	assert.Equal(2*DomainSize(ccs), DomainSize(padded))
+
+	inner := unit.prove(assert, unit.link(0))
+
+	comp := func(beginID, endID LinkageID) UnitCore[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT] {
+		return &testComp2Chain{BeginID: beginID, EndID: endID}
+	}
+	hybridAssignment := NewHybridAssignment[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		inner.vk, inner.proof, inner.witness,
+		[]common_utils.FingerPrint[sw_bls12377.ScalarField]{unit.recursiveFp()},
+		LinkageIDFromBytes(ids[0], 128), LinkageIDFromBytes(ids[1], 128), LinkageIDFromBytes(ids[2], 128),
+		comp(LinkageIDFromBytes(ids[1], 128), LinkageIDFromBytes(ids[2], 128)))
+
+	for _, ccsUnit := range []struct {
+		ccs      constraint.ConstraintSystem
+		expected bool
+	}{{ccs, true}, {padded, false}} {
+		circuit, err := NewHybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			2, 128, ccsUnit.ccs, []common_utils.FingerPrintBytes{fp}, 1,
+			comp(PlaceholderLinkageID(2, 128), PlaceholderLinkageID(2, 128)), true)
+		assert.NoError(err)
+		assert.Equal(DomainSize(ccsUnit.ccs), circuit.optimizedSize)
+
+		// the unit proof is in the size range of the circuit only if it was created upon the same unit
+		err = test.IsSolved(circuit, hybridAssignment, ecc.BW6_761.ScalarField())
+		if ccsUnit.expected {
+			assert.NoError(err)
+		} else {
+			assert.Error(err)
+		}
+	}
+
+	verifier, err := NewOptimizedVerifierCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		ccs, []common_utils.FingerPrintBytes{fp}, 2, 1, 1)
+	assert.NoError(err)
+	assert.Equal(DomainSize(ccs), verifier.optimizedSize)
+}
+
+func TestCheckOptimization(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	compile := func(nbExtra int) constraint.ConstraintSystem {
+		ccs, err := frontend.Compile(ecc.BLS12_377.ScalarField(), scs.NewBuilder, &testPaddedUnit2Chain{
+			testUnitCircuit2Chain: &testUnitCircuit2Chain{
+				MultiUnit: NewMultiUnitCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](2, 128, 1),
+			},
+			NbExtra: nbExtra,
+		})
+		assert.NoError(err)
+		return ccs
+	}
+	unit := compile(0)
+	large := compile(3 * int(DomainSize(unit)))
+
+	report, err := CheckOptimization(map[string]constraint.ConstraintSystem{"unit": unit, "large": large})
+	assert.True(errors.Is(err, ErrDomainSize))
+	assert.False(report.Sound())
+	assert.Equal(DomainSize(large), report.DomainSize)
+	assert.Equal("large", report.Circuits[0].Name)
+	paddings, err := report.Paddings(0)
+	assert.NoError(err)
+	assert.Equal(0, paddings[0])
+
+	// the padding is exact
+	assert.Equal(report.DomainSize, DomainSize(compile(paddings[1])))
+	assert.Equal(report.DomainSize/2, DomainSize(compile(paddings[1]-1)))
+	report, err = CheckOptimization(map[string]constraint.ConstraintSystem{"unit": compile(paddings[1]), "large": large})
+	assert.NoError(err)
+	assert.True(report.Sound())
+
+	// no circuit could be shrunk
+	_, err = report.Paddings(DomainSize(unit))
+	assert.True(errors.Is(err, ErrDomainSize))
+}
@@ -0,0 +1,84 @@
+package orchestrator
+
+import (
+	"sync"
+
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
+)
+
+type Keys struct {
+	CCS constraint.ConstraintSystem
+	PK  native_plonk.ProvingKey
+	VK  native_plonk.VerifyingKey
+}
+
+// KeyLoader loads the keys of a circuit by its name, or only its verifying key for the circuits of the inner proofs.
+type KeyLoader interface {
+	LoadKeys(name string) (*Keys, error)
+	LoadVerifyingKey(name string) (native_plonk.VerifyingKey, error)
+}
+
+type cacheEntry[T any] struct {
+	once  sync.Once
+	value T
+	err   error
+}
+
+// KeyCache loads the keys of each circuit once, concurrent callers waiting for the same circuit to be loaded. Errors
+// are not cached, so that the loading is tried again by the next call.
+type KeyCache struct {
+	loader KeyLoader
+
+	mu   sync.Mutex
+	keys map[string]*cacheEntry[*Keys]
+	vks  map[string]*cacheEntry[native_plonk.VerifyingKey]
+}
+
+func NewKeyCache(loader KeyLoader) *KeyCache {
+	return &KeyCache{
+		loader: loader,
+		keys:   make(map[string]*cacheEntry[*Keys]),
+		vks:    make(map[string]*cacheEntry[native_plonk.VerifyingKey]),
+	}
+}
+
+func (c *KeyCache) Keys(name string) (*Keys, error) {
+	return get(&c.mu, c.keys, name, c.loader.LoadKeys)
+}
+
+// VerifyingKey returns the verifying key of the circuit, without loading its ccs and proving key unless done already.
+func (c *KeyCache) VerifyingKey(name string) (native_plonk.VerifyingKey, error) {
+	c.mu.Lock()
+	_, loaded := c.keys[name]
+	c.mu.Unlock()
+	if loaded {
+		keys, err := c.Keys(name)
+		if err == nil {
+			return keys.VK, nil
+		}
+	}
+	return get(&c.mu, c.vks, name, c.loader.LoadVerifyingKey)
+}
+
+func get[T any](mu *sync.Mutex, entries map[string]*cacheEntry[T], name string, load func(string) (T, error)) (T, error) {
+	mu.Lock()
+	e, ok := entries[name]
+	if !ok {
+		e = &cacheEntry[T]{}
+		entries[name] = e
+	}
+	mu.Unlock()
+
+	e.once.Do(func() {
+		e.value, e.err = load(name)
+	})
+	if e.err != nil {
+		mu.Lock()
+		if entries[name] == e {
+			delete(entries, name)
+		}
+		mu.Unlock()
+	}
+	return e.value, e.err
+}
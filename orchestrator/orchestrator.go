@@ -0,0 +1,225 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lightec-xyz/chainark/planner"
+)
+
+/**
+ * The orchestrator executes the jobs of a plan with a bounded pool of workers. A job is started once all the jobs
+ * it depends on have succeeded, so that unit proofs are generated in parallel while recursive and hybrid steps wait
+ * for their inputs. Ready jobs are started in the order of the plan. A failed job is retried up to Retries times;
+ * once a job has failed for good, no more jobs are started, the running ones are cancelled through their context,
+ * and Run returns the error.
+ */
+
+var (
+	ErrBadPlan   = errors.New("orchestrator: bad plan")
+	ErrJobFailed = errors.New("orchestrator: job failed")
+)
+
+// Prover proves a job, whose inputs have all been proved. It should return early when ctx is cancelled.
+type Prover interface {
+	Prove(ctx context.Context, job *planner.Job) error
+}
+
+// ProverFunc adapts a function to the Prover interface.
+type ProverFunc func(ctx context.Context, job *planner.Job) error
+
+func (f ProverFunc) Prove(ctx context.Context, job *planner.Job) error {
+	return f(ctx, job)
+}
+
+type EventKind int
+
+const (
+	JobStarted EventKind = iota
+	JobSucceeded
+	JobRetrying
+	JobFailed
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case JobStarted:
+		return "started"
+	case JobSucceeded:
+		return "succeeded"
+	case JobRetrying:
+		return "retrying"
+	case JobFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+type Event struct {
+	Kind    EventKind
+	Index   int // index of the job in the plan
+	Job     *planner.Job
+	Attempt int   // starting from 1
+	Err     error // set for JobRetrying and JobFailed
+	Done    int   // number of jobs succeeded so far
+	Total   int
+}
+
+type Options struct {
+	Workers    int           // defaults to 1
+	Retries    int           // number of retries after the first attempt of a job
+	RetryDelay time.Duration // delay before retrying a job
+	OnEvent    func(Event)   // called by one goroutine at a time
+}
+
+type result struct {
+	index int
+	err   error
+}
+
+type orchestrator struct {
+	plan   *planner.Plan
+	prover Prover
+	opts   Options
+
+	mu   sync.Mutex // serializes the events
+	done int
+}
+
+// Run executes all the jobs of plan with prover.
+func Run(ctx context.Context, plan *planner.Plan, prover Prover, opts Options) error {
+	nbJobs := len(plan.Jobs)
+	pending := make([]int, nbJobs)
+	dependents := make([][]int, nbJobs)
+	ready := make([]int, 0)
+	for i, job := range plan.Jobs {
+		for _, dep := range job.Deps {
+			if dep < 0 || dep >= i {
+				return fmt.Errorf("%w: job %v depends on job %v", ErrBadPlan, i, dep)
+			}
+			dependents[dep] = append(dependents[dep], i)
+		}
+		pending[i] = len(job.Deps)
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	nbWorkers := opts.Workers
+	if nbWorkers <= 0 {
+		nbWorkers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	o := &orchestrator{plan: plan, prover: prover, opts: opts}
+	jobs := make(chan int)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for w := 0; w < nbWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results <- result{index: i, err: o.prove(ctx, i)}
+			}
+		}()
+	}
+
+	var err error
+	running, done := 0, 0
+	for done < nbJobs {
+		var send chan int
+		next := -1
+		if len(ready) != 0 && err == nil && ctx.Err() == nil {
+			send = jobs
+			next = ready[0]
+		}
+		if send == nil && running == 0 {
+			break
+		}
+
+		select {
+		case send <- next:
+			ready = ready[1:]
+			running++
+		case r := <-results:
+			running--
+			if r.err != nil {
+				if err == nil {
+					err = r.err
+					cancel()
+				}
+				continue
+			}
+			done++
+			for _, d := range dependents[r.index] {
+				pending[d]--
+				if pending[d] == 0 {
+					ready = append(ready, d)
+				}
+			}
+			sort.Ints(ready)
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	if done < nbJobs {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// prove proves job i, retrying it as configured.
+func (o *orchestrator) prove(ctx context.Context, i int) error {
+	job := &o.plan.Jobs[i]
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		o.emit(Event{Kind: JobStarted, Index: i, Job: job, Attempt: attempt})
+		err := o.prover.Prove(ctx, job)
+		if err == nil {
+			o.emit(Event{Kind: JobSucceeded, Index: i, Job: job, Attempt: attempt})
+			return nil
+		}
+
+		if attempt > o.opts.Retries || ctx.Err() != nil {
+			o.emit(Event{Kind: JobFailed, Index: i, Job: job, Attempt: attempt, Err: err})
+			return fmt.Errorf("%w: %v: %w", ErrJobFailed, job.Output, err)
+		}
+		o.emit(Event{Kind: JobRetrying, Index: i, Job: job, Attempt: attempt, Err: err})
+
+		select {
+		case <-time.After(o.opts.RetryDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (o *orchestrator) emit(e Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if e.Kind == JobSucceeded {
+		o.done++
+	}
+	if o.opts.OnEvent == nil {
+		return
+	}
+	e.Done = o.done
+	e.Total = len(o.plan.Jobs)
+	o.opts.OnEvent(e)
+}
@@ -0,0 +1,227 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/test"
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/planner"
+)
+
+func testPlan(assert *test.Assert) *planner.Plan {
+	ids := make([]chainark.LinkageIDBytes, 40)
+	for i := 0; i < len(ids); i++ {
+		ids[i] = chainark.LinkageIDBytes{byte(i)}
+	}
+	plan, err := planner.New(ids, planner.Options{
+		Units:  []planner.Variant{{Name: "unit_1", NbLinks: 1}, {Name: "unit_2", NbLinks: 2}, {Name: "unit_8", NbLinks: 8}},
+		Hybrid: &planner.Variant{Name: "hybrid", NbLinks: 4},
+	})
+	assert.NoError(err)
+	return plan
+}
+
+// fakeProver checks that the inputs of each job have been proved, and records the concurrency.
+type fakeProver struct {
+	mu       sync.Mutex
+	proved   map[string]bool
+	attempts map[string]int
+	running  int
+	maxRun   int
+	fail     func(job *planner.Job, attempt int) error
+}
+
+func (p *fakeProver) Prove(ctx context.Context, job *planner.Job) error {
+	p.mu.Lock()
+	for _, input := range job.Inputs {
+		if !p.proved[input.Artifact] {
+			p.mu.Unlock()
+			return errors.New("input not proved: " + input.Artifact)
+		}
+	}
+	p.running++
+	if p.running > p.maxRun {
+		p.maxRun = p.running
+	}
+	p.attempts[job.Output]++
+	attempt := p.attempts[job.Output]
+	p.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	var err error
+	if p.fail != nil {
+		err = p.fail(job, attempt)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running--
+	if err != nil {
+		return err
+	}
+	p.proved[job.Output] = true
+	return nil
+}
+
+func newFakeProver() *fakeProver {
+	return &fakeProver{proved: make(map[string]bool), attempts: make(map[string]int)}
+}
+
+func TestRun(t *testing.T) {
+	assert := test.NewAssert(t)
+	plan := testPlan(assert)
+
+	prover := newFakeProver()
+	var events []Event
+	err := Run(context.Background(), plan, prover, Options{
+		Workers: 3,
+		OnEvent: func(e Event) { events = append(events, e) },
+	})
+	assert.NoError(err)
+
+	assert.Equal(len(plan.Jobs), len(prover.proved))
+	assert.True(prover.maxRun <= 3)
+	assert.True(prover.maxRun > 1)
+
+	last := events[len(events)-1]
+	assert.Equal(JobSucceeded, last.Kind)
+	assert.Equal(len(plan.Jobs), last.Done)
+	assert.Equal(len(plan.Jobs), last.Total)
+	assert.Equal(plan.Final, last.Job.Output)
+}
+
+func TestRunRetries(t *testing.T) {
+	assert := test.NewAssert(t)
+	plan := testPlan(assert)
+
+	prover := newFakeProver()
+	prover.fail = func(job *planner.Job, attempt int) error {
+		if job.Kind != planner.KindUnit && attempt < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}
+
+	var retrying int32
+	err := Run(context.Background(), plan, prover, Options{
+		Workers: 2,
+		Retries: 2,
+		OnEvent: func(e Event) {
+			if e.Kind == JobRetrying {
+				atomic.AddInt32(&retrying, 1)
+			}
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(len(plan.Jobs), len(prover.proved))
+
+	nbSteps := 0
+	for _, job := range plan.Jobs {
+		if job.Kind != planner.KindUnit {
+			nbSteps++
+		}
+	}
+	assert.Equal(int32(2*nbSteps), retrying)
+}
+
+func TestRunFailure(t *testing.T) {
+	assert := test.NewAssert(t)
+	plan := testPlan(assert)
+
+	errProver := errors.New("out of memory")
+	prover := newFakeProver()
+	prover.fail = func(job *planner.Job, attempt int) error {
+		if job.Kind == planner.KindHybrid {
+			return errProver
+		}
+		return nil
+	}
+
+	err := Run(context.Background(), plan, prover, Options{Workers: 4, Retries: 1})
+	assert.True(errors.Is(err, ErrJobFailed))
+	assert.True(errors.Is(err, errProver))
+	assert.False(prover.proved[plan.Final])
+
+	// an invalid plan is rejected before proving anything
+	plan.Jobs[0].Deps = []int{1}
+	err = Run(context.Background(), plan, newFakeProver(), Options{})
+	assert.True(errors.Is(err, ErrBadPlan))
+}
+
+func TestRunCancelled(t *testing.T) {
+	assert := test.NewAssert(t)
+	plan := testPlan(assert)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	prover := ProverFunc(func(ctx context.Context, job *planner.Job) error {
+		cancel()
+		return ctx.Err()
+	})
+	err := Run(ctx, plan, prover, Options{Workers: 2, Retries: 3})
+	assert.True(errors.Is(err, context.Canceled))
+}
+
+type countingLoader struct {
+	keys, vks int32
+	fail      atomic.Bool
+}
+
+func (l *countingLoader) LoadKeys(name string) (*Keys, error) {
+	atomic.AddInt32(&l.keys, 1)
+	if l.fail.Load() {
+		return nil, errors.New("not found")
+	}
+	time.Sleep(time.Millisecond)
+	return &Keys{}, nil
+}
+
+func (l *countingLoader) LoadVerifyingKey(name string) (native_plonk.VerifyingKey, error) {
+	atomic.AddInt32(&l.vks, 1)
+	return nil, nil
+}
+
+func TestKeyCache(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	loader := &countingLoader{}
+	cache := NewKeyCache(loader)
+
+	// the verifying key only is loaded until the full keys are
+	_, err := cache.VerifyingKey("unit")
+	assert.NoError(err)
+	_, err = cache.VerifyingKey("unit")
+	assert.NoError(err)
+	assert.Equal(int32(1), loader.vks)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Keys("recursive")
+			assert.NoError(err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(int32(1), loader.keys)
+
+	_, err = cache.VerifyingKey("recursive")
+	assert.NoError(err)
+	assert.Equal(int32(1), loader.vks)
+
+	// errors are not cached
+	loader.fail.Store(true)
+	_, err = cache.Keys("hybrid")
+	assert.Error(err)
+	loader.fail.Store(false)
+	_, err = cache.Keys("hybrid")
+	assert.NoError(err)
+	assert.Equal(int32(3), loader.keys)
+}
@@ -0,0 +1,51 @@
+package chainark
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+)
+
+/**
+ * Padding adds NbConstraints cheap PLONK constraints to the circuit embedding it, so that circuits could be aligned on
+ * the same domain size, the prerequisite of the optimization. NbConstraints is a constant like NbSelfFps, computed at
+ * setup by NewPadding, or taken from OptimizationReport.Paddings, upon the circuit compiled without padding.
+ */
+type Padding struct {
+	NbConstraints int
+}
+
+// NewPadding returns the padding for the circuit compiled into ccs, without padding, to have a domain of the given size.
+func NewPadding(ccs constraint.ConstraintSystem, size uint64) (Padding, error) {
+	d := CircuitDomain{
+		Name:       "circuit",
+		NbRows:     ccs.GetNbConstraints() + ccs.GetNbPublicVariables(),
+		DomainSize: DomainSize(ccs),
+	}
+	nbConstraints, err := d.Padding(size)
+	if err != nil {
+		return Padding{}, err
+	}
+	return Padding{NbConstraints: nbConstraints}, nil
+}
+
+// Define squares seed, any variable of the circuit, NbConstraints times, each square being a single PLONK constraint.
+func (p Padding) Define(api frontend.API, seed frontend.Variable) error {
+	if p.NbConstraints < 0 {
+		return fmt.Errorf("%w: %v constraints", ErrBadPadding, p.NbConstraints)
+	}
+	if p.NbConstraints == 0 {
+		return nil
+	}
+	// constants would be folded at compile time
+	if _, isConstant := api.Compiler().ConstantValue(seed); isConstant {
+		return fmt.Errorf("%w: constant seed", ErrBadPadding)
+	}
+
+	x := seed
+	for i := 0; i < p.NbConstraints; i++ {
+		x = api.Mul(x, x)
+	}
+	return nil
+}
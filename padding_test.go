@@ -0,0 +1,65 @@
+package chainark
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+func TestPadding(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	newUnit := func(padding Padding) *testUnitCircuit2Chain {
+		unit := &testUnitCircuit2Chain{
+			MultiUnit: NewMultiUnitCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](2, 128, 1),
+		}
+		unit.Padding = padding
+		return unit
+	}
+	ccs, err := frontend.Compile(ecc.BLS12_377.ScalarField(), scs.NewBuilder, newUnit(Padding{}))
+	assert.NoError(err)
+
+	// a padded unit has exactly the target domain size, and the same assignment
+	padding, err := NewPadding(ccs, 4*DomainSize(ccs))
+	assert.NoError(err)
+	padded, err := frontend.Compile(ecc.BLS12_377.ScalarField(), scs.NewBuilder, newUnit(padding))
+	assert.NoError(err)
+	assert.Equal(4*DomainSize(ccs), DomainSize(padded))
+	assert.Equal(int(2*DomainSize(ccs))+1, padded.GetNbConstraints()+padded.GetNbPublicVariables())
+
+	ids := testIDs(assert)
+	assignment := &testUnitCircuit2Chain{
+		MultiUnit: NewMultiUnitAssignment[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			ids[0], ids[1], 128, 1),
+	}
+	assert.NoError(test.IsSolved(newUnit(padding), assignment, ecc.BLS12_377.ScalarField()))
+
+	// no padding shrinks a circuit
+	_, err = NewPadding(padded, DomainSize(ccs))
+	assert.True(errors.Is(err, ErrDomainSize))
+	_, err = frontend.Compile(ecc.BLS12_377.ScalarField(), scs.NewBuilder, newUnit(Padding{NbConstraints: -1}))
+	assert.True(errors.Is(err, ErrBadPadding))
+
+	// hybrid circuits are aligned the same way
+	fp := GetPlaceholderFp()
+	comp := &testComp2Chain{BeginID: PlaceholderLinkageID(2, 128), EndID: PlaceholderLinkageID(2, 128)}
+	hybrid, err := NewHybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, ccs, []common_utils.FingerPrintBytes{fp}, 1, comp, true)
+	assert.NoError(err)
+	hybridCcs, err := frontend.Compile(ecc.BW6_761.ScalarField(), scs.NewBuilder, hybrid)
+	assert.NoError(err)
+	hybrid.Padding, err = NewPadding(hybridCcs, 2*DomainSize(hybridCcs))
+	assert.NoError(err)
+	hybridCcs, err = frontend.Compile(ecc.BW6_761.ScalarField(), scs.NewBuilder, hybrid)
+	assert.NoError(err)
+	report, err := CheckOptimization(map[string]constraint.ConstraintSystem{"hybrid": hybridCcs})
+	assert.NoError(err)
+	assert.Equal(hybridCcs.GetNbConstraints()+hybridCcs.GetNbPublicVariables(), int(report.DomainSize/2)+1)
+}
@@ -0,0 +1,332 @@
+package planner
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/lightec-xyz/chainark"
+)
+
+/**
+ * The planner decomposes a chain of ids into segments proved by unit circuits (or verified directly by the hybrid
+ * circuit), then chains them with recursive and hybrid steps:
+ *
+ *   unit(0..a) + unit(a..b) -> recursive(0..b), recursive(0..b) + unit(b..c) -> recursive(0..c), ...
+ *   recursive(0..c) + component(c..d) -> hybrid(0..d), ...
+ *
+ * Each recursive or hybrid step costs about the same, far more than a unit proof, so the planner minimizes the
+ * number of segments. Among the decompositions with the fewest segments, it takes the largest segments first, and
+ * prefers the hybrid step over a unit proof of the same size as it saves a unit proof. The plan is deterministic.
+ *
+ * Extend plans the same way from an existing recursive or hybrid proof, which may then be followed by a hybrid step
+ * directly.
+ */
+
+type Kind string
+
+const (
+	KindUnit      Kind = "unit"
+	KindRecursive Kind = "recursive"
+	KindHybrid    Kind = "hybrid"
+)
+
+var (
+	ErrNoUnit     = errors.New("planner: no unit variant")
+	ErrBadVariant = errors.New("planner: bad variant")
+	ErrTooShort   = errors.New("planner: chain too short for a recursive proof")
+	ErrNoPlan     = errors.New("planner: links could not be covered by the variants")
+	ErrBadIDs     = errors.New("planner: bad ids")
+)
+
+// Variant is a unit circuit, or the hybrid component, proving NbLinks links.
+type Variant struct {
+	Name    string
+	NbLinks int
+}
+
+type Options struct {
+	Units  []Variant
+	Hybrid *Variant // nil if no hybrid circuit is set up
+}
+
+// Input is an artifact consumed by a job, along with the circuit that generated it.
+type Input struct {
+	Artifact string
+	Circuit  string
+}
+
+// Job proves the links from ids[Begin] to ids[End]. Recursive and hybrid jobs split them at ids[Relay], where the
+// first input ends.
+type Job struct {
+	Kind    Kind
+	Circuit string // the unit variant name, recursive or hybrid
+
+	Begin, Relay, End       int
+	BeginID, RelayID, EndID chainark.LinkageIDBytes
+
+	Inputs []Input
+	Deps   []int // indexes of the jobs generating the inputs, all lower than the index of this job
+	Output string
+}
+
+type Plan struct {
+	Jobs  []Job // in a topological order, unit jobs first
+	Final string
+}
+
+type segment struct {
+	variant Variant
+	hybrid  bool
+}
+
+func ArtifactName(kind Kind, begin, end int) string {
+	return fmt.Sprintf("%v_%v_%v", kind, begin, end)
+}
+
+// New plans the proving jobs for the chain ids[0] -> ids[len(ids)-1], one link between each pair of adjacent ids.
+func New(ids []chainark.LinkageIDBytes, opts Options) (*Plan, error) {
+	if len(ids) < 2 {
+		return nil, fmt.Errorf("%w: %v ids", ErrTooShort, len(ids))
+	}
+	err := checkIDs(ids, len(ids[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := decompose(len(ids)-1, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	return build(ids[0], ids, 0, segments, nil), nil
+}
+
+// Extend plans the proving jobs extending tip, a proof from genesisID to ids[0], to ids[len(ids)-1]. Indexes are
+// counted from genesisID, ids[0] being at index offset. Unlike New, the plan could start with a hybrid step.
+func Extend(genesisID chainark.LinkageIDBytes, tip Input, offset int, ids []chainark.LinkageIDBytes, opts Options) (*Plan, error) {
+	if len(ids) < 2 {
+		return nil, fmt.Errorf("%w: %v ids", ErrTooShort, len(ids))
+	}
+	if offset <= 0 {
+		return nil, fmt.Errorf("%w: tip at index %v", ErrBadIDs, offset)
+	}
+	err := checkIDs(ids, len(genesisID))
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := decompose(len(ids)-1, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	return build(genesisID, ids, offset, segments, &tip), nil
+}
+
+func checkIDs(ids []chainark.LinkageIDBytes, size int) error {
+	for i := 0; i < len(ids); i++ {
+		if len(ids[i]) != size {
+			return fmt.Errorf("%w: id %v has %v bytes, expecting %v", ErrBadIDs, i, len(ids[i]), size)
+		}
+	}
+	return nil
+}
+
+// build lays out the jobs of segments over ids, ids[0] being at index offset. Without tip, the first segment is the
+// first proof of the chain.
+func build(genesisID chainark.LinkageIDBytes, ids []chainark.LinkageIDBytes, offset int, segments []segment, tip *Input) *Plan {
+	plan := &Plan{}
+	addJob := func(job Job) int {
+		plan.Jobs = append(plan.Jobs, job)
+		return len(plan.Jobs) - 1
+	}
+
+	// unit jobs first, they are independent of each other
+	unitJobs := make([]int, len(segments))
+	pos := 0
+	for i, s := range segments {
+		end := pos + s.variant.NbLinks
+		if !s.hybrid {
+			unitJobs[i] = addJob(Job{
+				Kind:    KindUnit,
+				Circuit: s.variant.Name,
+				Begin:   offset + pos,
+				End:     offset + end,
+				BeginID: ids[pos],
+				EndID:   ids[end],
+				Output:  ArtifactName(KindUnit, offset+pos, offset+end),
+			})
+		}
+		pos = end
+	}
+
+	last := -1
+	var lastInput Input
+	pos, first := 0, 0
+	if tip != nil {
+		lastInput = *tip
+	} else {
+		last = unitJobs[0]
+		lastInput = Input{Artifact: plan.Jobs[last].Output, Circuit: segments[0].variant.Name}
+		pos, first = segments[0].variant.NbLinks, 1
+	}
+
+	for i := first; i < len(segments); i++ {
+		s := segments[i]
+		end := pos + s.variant.NbLinks
+		job := Job{
+			Kind:    KindRecursive,
+			Circuit: string(KindRecursive),
+			Begin:   0,
+			Relay:   offset + pos,
+			End:     offset + end,
+			BeginID: genesisID,
+			RelayID: ids[pos],
+			EndID:   ids[end],
+			Inputs:  []Input{lastInput},
+		}
+		if last >= 0 {
+			job.Deps = []int{last}
+		}
+		if s.hybrid {
+			job.Kind = KindHybrid
+			job.Circuit = string(KindHybrid)
+		} else {
+			job.Inputs = append(job.Inputs, Input{Artifact: plan.Jobs[unitJobs[i]].Output, Circuit: s.variant.Name})
+			job.Deps = append(job.Deps, unitJobs[i])
+		}
+		job.Output = ArtifactName(job.Kind, 0, offset+end)
+
+		last = addJob(job)
+		lastInput = Input{Artifact: job.Output, Circuit: job.Circuit}
+		pos = end
+	}
+
+	plan.Final = plan.Jobs[last].Output
+	return plan
+}
+
+// decompose splits nbLinks into segments. From the genesis, there are at least 2 segments, the first one being a
+// unit proof. From a tip, any decomposition would do.
+func decompose(nbLinks int, opts Options, fromTip bool) ([]segment, error) {
+	if len(opts.Units) == 0 {
+		return nil, ErrNoUnit
+	}
+
+	// candidates ordered by decreasing size, the hybrid step before a unit of the same size
+	candidates := make([]segment, 0, len(opts.Units)+1)
+	names := make(map[string]bool)
+	for _, u := range opts.Units {
+		if u.NbLinks <= 0 || u.Name == "" || names[u.Name] {
+			return nil, fmt.Errorf("%w: %+v", ErrBadVariant, u)
+		}
+		names[u.Name] = true
+		candidates = append(candidates, segment{variant: u})
+	}
+	if opts.Hybrid != nil {
+		if opts.Hybrid.NbLinks <= 0 {
+			return nil, fmt.Errorf("%w: hybrid %+v", ErrBadVariant, *opts.Hybrid)
+		}
+		candidates = append(candidates, segment{variant: *opts.Hybrid, hybrid: true})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].variant.NbLinks != candidates[j].variant.NbLinks {
+			return candidates[i].variant.NbLinks > candidates[j].variant.NbLinks
+		}
+		return candidates[i].hybrid && !candidates[j].hybrid
+	})
+
+	// best[p] is the fewest segments covering the links from p to nbLinks, -1 if impossible
+	best := make([]int, nbLinks+1)
+	choice := make([]int, nbLinks+1)
+	for p := nbLinks - 1; p >= 0; p-- {
+		best[p] = -1
+		for c, s := range candidates {
+			next := p + s.variant.NbLinks
+			if next > nbLinks || best[next] < 0 {
+				continue
+			}
+			if best[p] < 0 || best[next]+1 < best[p] {
+				best[p] = best[next] + 1
+				choice[p] = c
+			}
+		}
+	}
+
+	var segments []segment
+	p := 0
+	if fromTip {
+		if best[0] < 0 {
+			return nil, fmt.Errorf("%w: %v links", ErrNoPlan, nbLinks)
+		}
+	} else {
+		// the first segment is a unit proof, which must not cover the whole chain
+		first, firstCost := -1, -1
+		for c, s := range candidates {
+			n := s.variant.NbLinks
+			if s.hybrid || n >= nbLinks || best[n] < 0 {
+				continue
+			}
+			if firstCost < 0 || best[n]+1 < firstCost {
+				first, firstCost = c, best[n]+1
+			}
+		}
+		if first < 0 {
+			if nbLinks == 1 {
+				return nil, fmt.Errorf("%w: 1 link", ErrTooShort)
+			}
+			return nil, fmt.Errorf("%w: %v links", ErrNoPlan, nbLinks)
+		}
+		segments = append(segments, candidates[first])
+		p = candidates[first].variant.NbLinks
+	}
+
+	for ; p < nbLinks; p += candidates[choice[p]].variant.NbLinks {
+		segments = append(segments, candidates[choice[p]])
+	}
+	return segments, nil
+}
+
+// ReadIDs reads hex ids, one per line as in example/ids.txt, skipping empty lines.
+func ReadIDs(r io.Reader) ([]chainark.LinkageIDBytes, error) {
+	var ids []chainark.LinkageIDBytes
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+		id, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %v: %v", ErrBadIDs, line, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Args returns the arguments of the chainark command line tool running the job.
+func (j *Job) Args() []string {
+	switch j.Kind {
+	case KindUnit:
+		return []string{"prove-unit", "-unit", j.Circuit,
+			"-begin", hex.EncodeToString(j.BeginID), "-end", hex.EncodeToString(j.EndID), "-out", j.Output}
+	case KindRecursive:
+		return []string{"prove-recursive",
+			"-first", j.Inputs[0].Artifact, "-first-circuit", j.Inputs[0].Circuit,
+			"-second", j.Inputs[1].Artifact, "-second-unit", j.Inputs[1].Circuit,
+			"-begin", hex.EncodeToString(j.BeginID), "-relay", hex.EncodeToString(j.RelayID),
+			"-end", hex.EncodeToString(j.EndID), "-out", j.Output}
+	default:
+		return []string{"prove-hybrid",
+			"-first", j.Inputs[0].Artifact, "-first-circuit", j.Inputs[0].Circuit,
+			"-begin", hex.EncodeToString(j.BeginID), "-relay", hex.EncodeToString(j.RelayID),
+			"-end", hex.EncodeToString(j.EndID), "-out", j.Output}
+	}
+}
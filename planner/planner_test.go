@@ -0,0 +1,168 @@
+package planner
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark/test"
+	"github.com/lightec-xyz/chainark"
+)
+
+func testIDs(n int) []chainark.LinkageIDBytes {
+	ids := make([]chainark.LinkageIDBytes, n)
+	for i := 0; i < n; i++ {
+		ids[i] = chainark.LinkageIDBytes{byte(i), byte(i >> 8)}
+	}
+	return ids
+}
+
+func exampleOptions() Options {
+	return Options{
+		Units: []Variant{{"unit_1", 1}, {"unit_2", 2}, {"unit_4", 4}, {"unit_8", 8}},
+	}
+}
+
+func segmentSizes(plan *Plan) []int {
+	var ret []int
+	for _, job := range plan.Jobs {
+		if job.Kind == KindUnit && job.Begin == 0 {
+			ret = append(ret, job.End)
+		}
+		if job.Kind != KindUnit {
+			ret = append(ret, job.End-job.Relay)
+		}
+	}
+	return ret
+}
+
+func TestPlanExample(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	f, err := os.Open("../example/ids.txt")
+	assert.NoError(err)
+	defer f.Close()
+	ids, err := ReadIDs(f)
+	assert.NoError(err)
+
+	// 0..23 as in recursive_prove.sh
+	plan, err := New(ids[:24], exampleOptions())
+	assert.NoError(err)
+	assert.Equal([]int{8, 8, 4, 2, 1}, segmentSizes(plan))
+	assert.Equal("recursive_0_23", plan.Final)
+
+	for i, job := range plan.Jobs {
+		assert.True(len(job.Deps) == len(job.Inputs))
+		for j, dep := range job.Deps {
+			assert.True(dep < i)
+			assert.Equal(plan.Jobs[dep].Output, job.Inputs[j].Artifact)
+			assert.Equal(plan.Jobs[dep].End, job.Relay+j*(job.End-job.Relay))
+		}
+		assert.Equal(ids[job.Begin], job.BeginID)
+		assert.Equal(ids[job.End], job.EndID)
+	}
+
+	again, err := New(ids[:24], exampleOptions())
+	assert.NoError(err)
+	assert.True(reflect.DeepEqual(plan, again))
+}
+
+func TestPlanHybrid(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	opts := exampleOptions()
+	opts.Hybrid = &Variant{"hybrid", 4}
+	plan, err := New(testIDs(24), opts)
+	assert.NoError(err)
+	assert.Equal([]int{8, 8, 4, 2, 1}, segmentSizes(plan))
+
+	// the segment of 4 links is verified by the hybrid circuit, saving a unit proof
+	hybrid := plan.Jobs[len(plan.Jobs)-3]
+	assert.Equal(KindHybrid, hybrid.Kind)
+	assert.Equal(1, len(hybrid.Inputs))
+	assert.Equal("recursive", hybrid.Inputs[0].Circuit)
+	assert.Equal("hybrid", plan.Jobs[len(plan.Jobs)-2].Inputs[0].Circuit)
+	nbUnits := 0
+	for _, job := range plan.Jobs {
+		if job.Kind == KindUnit {
+			nbUnits++
+		}
+	}
+	assert.Equal(4, nbUnits)
+}
+
+func TestPlanFewestSegments(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// taking the largest unit first would not cover 6 links
+	plan, err := New(testIDs(7), Options{Units: []Variant{{"a", 4}, {"b", 3}}})
+	assert.NoError(err)
+	assert.Equal([]int{3, 3}, segmentSizes(plan))
+
+	// the first proof never covers the whole chain
+	plan, err = New(testIDs(9), exampleOptions())
+	assert.NoError(err)
+	assert.Equal([]int{4, 4}, segmentSizes(plan))
+}
+
+func TestPlanErrors(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	_, err := New(testIDs(2), exampleOptions())
+	assert.True(errors.Is(err, ErrTooShort))
+
+	_, err = New(testIDs(6), Options{Units: []Variant{{"a", 2}}})
+	assert.True(errors.Is(err, ErrNoPlan))
+
+	_, err = New(testIDs(6), Options{})
+	assert.True(errors.Is(err, ErrNoUnit))
+
+	_, err = New(testIDs(6), Options{Units: []Variant{{"a", 1}, {"a", 2}}})
+	assert.True(errors.Is(err, ErrBadVariant))
+
+	ids := testIDs(6)
+	ids[3] = ids[3][:1]
+	_, err = New(ids, exampleOptions())
+	assert.True(errors.Is(err, ErrBadIDs))
+}
+
+func TestPlanExtend(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	ids := testIDs(24)
+	opts := exampleOptions()
+	opts.Hybrid = &Variant{"hybrid", 4}
+	tip := Input{Artifact: "recursive_0_10", Circuit: "recursive"}
+
+	// a hybrid step may extend the tip directly
+	plan, err := Extend(ids[0], tip, 10, ids[10:15], opts)
+	assert.NoError(err)
+	assert.Equal(1, len(plan.Jobs))
+	assert.Equal(KindHybrid, plan.Jobs[0].Kind)
+	assert.Equal([]Input{tip}, plan.Jobs[0].Inputs)
+	assert.Equal(0, len(plan.Jobs[0].Deps))
+	assert.Equal("hybrid_0_14", plan.Final)
+
+	plan, err = Extend(ids[0], tip, 10, ids[10:], opts)
+	assert.NoError(err)
+	assert.Equal([]int{8, 4, 1}, segmentSizes(plan))
+	assert.Equal("recursive_0_23", plan.Final)
+	for _, job := range plan.Jobs {
+		assert.Equal(ids[job.End], job.EndID)
+		if job.Kind == KindUnit {
+			assert.Equal(ids[job.Begin], job.BeginID)
+		} else {
+			assert.Equal(ids[0], job.BeginID)
+			assert.Equal(ids[job.Relay], job.RelayID)
+		}
+	}
+	assert.Equal(tip, plan.Jobs[len(plan.Jobs)-3].Inputs[0])
+
+	_, err = Extend(ids[0], tip, 10, ids[10:11], opts)
+	assert.True(errors.Is(err, ErrTooShort))
+	_, err = Extend(ids[0], tip, 0, ids[10:], opts)
+	assert.True(errors.Is(err, ErrBadIDs))
+	_, err = Extend(ids[0], tip, 10, ids[10:14], Options{Units: []Variant{{"a", 2}}})
+	assert.True(errors.Is(err, ErrNoPlan))
+}
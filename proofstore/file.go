@@ -0,0 +1,141 @@
+package proofstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lightec-xyz/chainark/internal/atomicfile"
+)
+
+// FileStore keeps each record as three files in a directory: <hash>.proof, <hash>.wtns and <hash>.json for the
+// metadata. The metadata file is written last, so that a record is only indexed once complete. The index is loaded
+// when the store is opened.
+type FileStore struct {
+	dir string
+
+	mu    sync.RWMutex
+	index map[Hash]Meta
+}
+
+// OpenFileStore opens the store in dir, creating dir if needed.
+func OpenFileStore(dir string) (*FileStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{dir: dir, index: make(map[Hash]Meta)}
+	for _, file := range files {
+		h, err := ParseHash(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var meta Meta
+		err = json.Unmarshal(data, &meta)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", file, err)
+		}
+		s.index[h] = meta
+	}
+	return s, nil
+}
+
+func (s *FileStore) path(h Hash, ext string) string {
+	return filepath.Join(s.dir, h.String()+ext)
+}
+
+func (s *FileStore) Put(r *Record) (Hash, error) {
+	err := r.Meta.validate()
+	if err != nil {
+		return Hash{}, err
+	}
+	h := r.Hash()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.index[h]; ok {
+		return h, nil
+	}
+
+	meta, err := json.MarshalIndent(r.Meta, "", "  ")
+	if err != nil {
+		return Hash{}, err
+	}
+	err = atomicfile.WriteFile(s.path(h, ".proof"), r.Proof)
+	if err != nil {
+		return Hash{}, err
+	}
+	err = atomicfile.WriteFile(s.path(h, ".wtns"), r.Witness)
+	if err != nil {
+		return Hash{}, err
+	}
+	err = atomicfile.WriteFile(s.path(h, ".json"), meta)
+	if err != nil {
+		return Hash{}, err
+	}
+	s.index[h] = r.Meta
+	return h, nil
+}
+
+func (s *FileStore) Get(h Hash) (*Record, error) {
+	s.mu.RLock()
+	meta, ok := s.index[h]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, h)
+	}
+
+	proof, err := os.ReadFile(s.path(h, ".proof"))
+	if err != nil {
+		return nil, err
+	}
+	witness, err := os.ReadFile(s.path(h, ".wtns"))
+	if err != nil {
+		return nil, err
+	}
+	r := &Record{Meta: meta, Proof: proof, Witness: witness}
+	if r.Hash() != h {
+		return nil, fmt.Errorf("%w: %v does not match its content", ErrBadRecord, h)
+	}
+	return r, nil
+}
+
+func (s *FileStore) List() ([]Entry, error) {
+	s.mu.RLock()
+	entries := make([]Entry, 0, len(s.index))
+	for h, meta := range s.index {
+		entries = append(entries, Entry{Hash: h, Meta: meta})
+	}
+	s.mu.RUnlock()
+
+	sortEntries(entries)
+	return entries, nil
+}
+
+func (s *FileStore) Exact(begin, end int) (*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return exact(entries, begin, end)
+}
+
+func (s *FileStore) Best(begin, end int) (*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return best(entries, begin, end)
+}
@@ -0,0 +1,77 @@
+package proofstore
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MemoryStore keeps the records in memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[Hash]*Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[Hash]*Record)}
+}
+
+func (s *MemoryStore) Put(r *Record) (Hash, error) {
+	err := r.Meta.validate()
+	if err != nil {
+		return Hash{}, err
+	}
+	h := r.Hash()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[h]; !ok {
+		s.records[h] = copyRecord(r)
+	}
+	return h, nil
+}
+
+func (s *MemoryStore) Get(h Hash) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.records[h]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, h)
+	}
+	return copyRecord(r), nil
+}
+
+func (s *MemoryStore) List() ([]Entry, error) {
+	s.mu.RLock()
+	entries := make([]Entry, 0, len(s.records))
+	for h, r := range s.records {
+		entries = append(entries, Entry{Hash: h, Meta: r.Meta})
+	}
+	s.mu.RUnlock()
+
+	sortEntries(entries)
+	return entries, nil
+}
+
+func (s *MemoryStore) Exact(begin, end int) (*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return exact(entries, begin, end)
+}
+
+func (s *MemoryStore) Best(begin, end int) (*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return best(entries, begin, end)
+}
+
+func copyRecord(r *Record) *Record {
+	return &Record{
+		Meta:    r.Meta,
+		Proof:   append([]byte(nil), r.Proof...),
+		Witness: append([]byte(nil), r.Witness...),
+	}
+}
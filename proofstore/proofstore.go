@@ -0,0 +1,255 @@
+package proofstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/planner"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * A proof store keeps proofs with their public witness, keyed by the hash of their content, along with metadata
+ * describing the chain they prove. Storing the same proof twice is a no-op, and proofs of different circuits never
+ * collide as file names such as recursive_0_19 could. Exact looks up the proof of a range of the chain, Best the
+ * proof covering a range from its begin.
+ */
+
+var (
+	ErrNotFound  = errors.New("proofstore: proof not found")
+	ErrBadRecord = errors.New("proofstore: bad record")
+)
+
+type Hash [sha256.Size]byte
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) != len(h) {
+		return h, fmt.Errorf("%w: hash %q", ErrBadRecord, s)
+	}
+	copy(h[:], data)
+	return h, nil
+}
+
+// Meta describes a proof of the links from BeginID at index Begin to EndID at index End.
+type Meta struct {
+	Kind        planner.Kind
+	Circuit     string // the unit variant name, recursive or hybrid
+	FingerPrint common_utils.FingerPrintBytes
+	BeginID     chainark.LinkageIDBytes
+	EndID       chainark.LinkageIDBytes
+	Begin, End  int
+	Created     time.Time
+}
+
+type metaJSON struct {
+	Kind        planner.Kind `json:"kind"`
+	Circuit     string       `json:"circuit"`
+	FingerPrint string       `json:"fingerPrint"`
+	BeginID     string       `json:"beginId"`
+	EndID       string       `json:"endId"`
+	Begin       int          `json:"begin"`
+	End         int          `json:"end"`
+	Created     time.Time    `json:"created"`
+}
+
+func (m Meta) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metaJSON{
+		Kind:        m.Kind,
+		Circuit:     m.Circuit,
+		FingerPrint: hex.EncodeToString(m.FingerPrint),
+		BeginID:     hex.EncodeToString(m.BeginID),
+		EndID:       hex.EncodeToString(m.EndID),
+		Begin:       m.Begin,
+		End:         m.End,
+		Created:     m.Created,
+	})
+}
+
+func (m *Meta) UnmarshalJSON(data []byte) error {
+	var v metaJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	fp, err := hex.DecodeString(v.FingerPrint)
+	if err != nil {
+		return fmt.Errorf("%w: fingerprint: %v", ErrBadRecord, err)
+	}
+	beginID, err := hex.DecodeString(v.BeginID)
+	if err != nil {
+		return fmt.Errorf("%w: begin id: %v", ErrBadRecord, err)
+	}
+	endID, err := hex.DecodeString(v.EndID)
+	if err != nil {
+		return fmt.Errorf("%w: end id: %v", ErrBadRecord, err)
+	}
+
+	*m = Meta{
+		Kind:        v.Kind,
+		Circuit:     v.Circuit,
+		FingerPrint: fp,
+		BeginID:     beginID,
+		EndID:       endID,
+		Begin:       v.Begin,
+		End:         v.End,
+		Created:     v.Created,
+	}
+	return nil
+}
+
+func (m *Meta) validate() error {
+	switch m.Kind {
+	case planner.KindUnit, planner.KindRecursive, planner.KindHybrid:
+	default:
+		return fmt.Errorf("%w: kind %q", ErrBadRecord, m.Kind)
+	}
+	if m.Begin < 0 || m.End <= m.Begin {
+		return fmt.Errorf("%w: range %v..%v", ErrBadRecord, m.Begin, m.End)
+	}
+	if len(m.BeginID) == 0 || len(m.BeginID) != len(m.EndID) {
+		return fmt.Errorf("%w: ids %x, %x", ErrBadRecord, m.BeginID, m.EndID)
+	}
+	return nil
+}
+
+// Record is a serialized proof and public witness, along with their metadata.
+type Record struct {
+	Meta    Meta
+	Proof   []byte
+	Witness []byte
+}
+
+// NewRecord serializes proof and its public witness. Created is set to now.
+func NewRecord(meta Meta, proof native_plonk.Proof, pubWitness witness.Witness) (*Record, error) {
+	var buf bytes.Buffer
+	_, err := proof.WriteTo(&buf)
+	if err != nil {
+		return nil, err
+	}
+	w, err := pubWitness.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	meta.Created = time.Now().UTC()
+	return &Record{Meta: meta, Proof: buf.Bytes(), Witness: w}, nil
+}
+
+// Decode deserializes the proof and the public witness of a circuit defined over curve.
+func (r *Record) Decode(curve ecc.ID) (native_plonk.Proof, witness.Witness, error) {
+	proof := native_plonk.NewProof(curve)
+	_, err := proof.ReadFrom(bytes.NewReader(r.Proof))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: proof: %v", ErrBadRecord, err)
+	}
+	w, err := witness.New(curve.ScalarField())
+	if err != nil {
+		return nil, nil, err
+	}
+	err = w.UnmarshalBinary(r.Witness)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: witness: %v", ErrBadRecord, err)
+	}
+	return proof, w, nil
+}
+
+// Hash returns the hash of the proof and the witness, the metadata being left out.
+func (r *Record) Hash() Hash {
+	h := sha256.New()
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(len(r.Proof)))
+	h.Write(size[:])
+	h.Write(r.Proof)
+	h.Write(r.Witness)
+	var ret Hash
+	h.Sum(ret[:0])
+	return ret
+}
+
+type Entry struct {
+	Hash Hash
+	Meta Meta
+}
+
+type ProofStore interface {
+	// Put stores r, returning its hash. Storing a proof already stored keeps the first metadata.
+	Put(r *Record) (Hash, error)
+	// Get returns the record of hash h, or ErrNotFound.
+	Get(h Hash) (*Record, error)
+	// List returns the entries ordered by Begin, End then creation time.
+	List() ([]Entry, error)
+	// Exact returns the newest entry of the proofs of exactly the links from index begin to index end, or ErrNotFound.
+	Exact(begin, end int) (*Entry, error)
+	// Best returns the entry of the proof covering the links from index begin to index end the closest, see best, or
+	// ErrNotFound.
+	Best(begin, end int) (*Entry, error)
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := &entries[i].Meta, &entries[j].Meta
+		if a.Begin != b.Begin {
+			return a.Begin < b.Begin
+		}
+		if a.End != b.End {
+			return a.End < b.End
+		}
+		if !a.Created.Equal(b.Created) {
+			return a.Created.Before(b.Created)
+		}
+		return bytes.Compare(entries[i].Hash[:], entries[j].Hash[:]) < 0
+	})
+}
+
+// exact picks, among the entries of exactly begin..end, the newest one. A proof of a containing range is not a proof
+// of begin..end: its public witness holds other begin or end ids.
+func exact(entries []Entry, begin, end int) (*Entry, error) {
+	return newest(entries, func(m *Meta) bool { return m.Begin == begin && m.End == end }, begin, end)
+}
+
+// best picks, among the entries starting at begin and ending at end or later, those of the smallest End, then the
+// newest one. Being sorted, entries of the same End and creation time are picked by the smallest hash.
+func best(entries []Entry, begin, end int) (*Entry, error) {
+	minEnd := -1
+	for i := range entries {
+		m := &entries[i].Meta
+		if m.Begin == begin && m.End >= end && (minEnd < 0 || m.End < minEnd) {
+			minEnd = m.End
+		}
+	}
+	return newest(entries, func(m *Meta) bool { return m.Begin == begin && m.End == minEnd }, begin, end)
+}
+
+func newest(entries []Entry, match func(m *Meta) bool, begin, end int) (*Entry, error) {
+	var ret *Entry
+	for i := range entries {
+		e := &entries[i]
+		if !match(&e.Meta) {
+			continue
+		}
+		if ret == nil || e.Meta.Created.After(ret.Meta.Created) {
+			ret = e
+		}
+	}
+	if ret == nil {
+		return nil, fmt.Errorf("%w: of %v..%v", ErrNotFound, begin, end)
+	}
+	entry := *ret
+	return &entry, nil
+}
@@ -0,0 +1,192 @@
+package proofstore
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/lightec-xyz/chainark"
+	"github.com/lightec-xyz/chainark/planner"
+)
+
+type squareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *squareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+func testMeta(kind planner.Kind, begin, end int) Meta {
+	return Meta{
+		Kind:        kind,
+		Circuit:     string(kind),
+		FingerPrint: []byte{1, 2, 3},
+		BeginID:     chainark.LinkageIDBytes{byte(begin)},
+		EndID:       chainark.LinkageIDBytes{byte(end)},
+		Begin:       begin,
+		End:         end,
+	}
+}
+
+// testRecord returns a record of fake content, distinct for each range.
+func testRecord(kind planner.Kind, begin, end int, created time.Time) *Record {
+	meta := testMeta(kind, begin, end)
+	meta.Created = created
+	return &Record{Meta: meta, Proof: []byte{byte(begin), byte(end)}, Witness: []byte(kind)}
+}
+
+func TestRecord(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &squareCircuit{})
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+	pk, vk, err := native_plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+
+	w, err := frontend.NewWitness(&squareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	pubWitness, err := w.Public()
+	assert.NoError(err)
+	proof, err := native_plonk.Prove(ccs, pk, w)
+	assert.NoError(err)
+
+	r, err := NewRecord(testMeta(planner.KindUnit, 0, 4), proof, pubWitness)
+	assert.NoError(err)
+	assert.False(r.Meta.Created.IsZero())
+
+	store, err := OpenFileStore(t.TempDir())
+	assert.NoError(err)
+	h, err := store.Put(r)
+	assert.NoError(err)
+	got, err := store.Get(h)
+	assert.NoError(err)
+
+	decodedProof, decodedWitness, err := got.Decode(ecc.BN254)
+	assert.NoError(err)
+	assert.NoError(native_plonk.Verify(decodedProof, vk, decodedWitness))
+}
+
+func testStore(assert *test.Assert, store ProofStore) {
+	now := time.Now().UTC().Truncate(time.Second)
+	unit := testRecord(planner.KindUnit, 0, 8, now)
+	h, err := store.Put(unit)
+	assert.NoError(err)
+	assert.Equal(unit.Hash(), h)
+
+	// the same content again keeps the first metadata
+	again := testRecord(planner.KindUnit, 0, 8, now.Add(time.Hour))
+	h2, err := store.Put(again)
+	assert.NoError(err)
+	assert.Equal(h, h2)
+	got, err := store.Get(h)
+	assert.NoError(err)
+	assert.True(got.Meta.Created.Equal(now))
+	assert.Equal(unit.Proof, got.Proof)
+	assert.Equal(unit.Witness, got.Witness)
+	assert.Equal(unit.Meta.BeginID, got.Meta.BeginID)
+
+	for _, r := range []*Record{
+		testRecord(planner.KindRecursive, 0, 12, now),
+		testRecord(planner.KindHybrid, 0, 19, now),
+		testRecord(planner.KindRecursive, 0, 23, now),
+		testRecord(planner.KindUnit, 19, 23, now),
+	} {
+		_, err = store.Put(r)
+		assert.NoError(err)
+	}
+
+	entries, err := store.List()
+	assert.NoError(err)
+	assert.Equal(5, len(entries))
+	assert.Equal(8, entries[0].Meta.End)
+	assert.Equal(19, entries[4].Meta.Begin)
+
+	e, err := store.Exact(0, 19)
+	assert.NoError(err)
+	assert.Equal(planner.KindHybrid, e.Meta.Kind)
+	e, err = store.Exact(19, 23)
+	assert.NoError(err)
+	assert.Equal(planner.KindUnit, e.Meta.Kind)
+
+	// a proof of a containing range does not prove a range
+	_, err = store.Exact(0, 13)
+	assert.True(errors.Is(err, ErrNotFound))
+	_, err = store.Exact(20, 21)
+	assert.True(errors.Is(err, ErrNotFound))
+
+	// but covers it from the same begin, the closest end first
+	e, err = store.Best(0, 13)
+	assert.NoError(err)
+	assert.Equal(19, e.Meta.End)
+	e, err = store.Best(0, 8)
+	assert.NoError(err)
+	assert.Equal(8, e.Meta.End)
+	e, err = store.Best(0, 20)
+	assert.NoError(err)
+	assert.Equal(23, e.Meta.End)
+	_, err = store.Best(20, 21)
+	assert.True(errors.Is(err, ErrNotFound))
+
+	// a newer proof of the same range is preferred
+	newer := testRecord(planner.KindRecursive, 0, 19, now.Add(time.Minute))
+	h, err = store.Put(newer)
+	assert.NoError(err)
+	e, err = store.Exact(0, 19)
+	assert.NoError(err)
+	assert.Equal(h, e.Hash)
+	e, err = store.Best(0, 13)
+	assert.NoError(err)
+	assert.Equal(h, e.Hash)
+
+	_, err = store.Exact(0, 24)
+	assert.True(errors.Is(err, ErrNotFound))
+	_, err = store.Best(0, 24)
+	assert.True(errors.Is(err, ErrNotFound))
+	_, err = store.Get(Hash{})
+	assert.True(errors.Is(err, ErrNotFound))
+	_, err = store.Put(testRecord(planner.KindUnit, 3, 3, now))
+	assert.True(errors.Is(err, ErrBadRecord))
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(test.NewAssert(t), NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir)
+	assert.NoError(err)
+	testStore(assert, store)
+
+	// the index is loaded again when reopened
+	reopened, err := OpenFileStore(dir)
+	assert.NoError(err)
+	entries, err := reopened.List()
+	assert.NoError(err)
+	expected, err := store.List()
+	assert.NoError(err)
+	assert.Equal(len(expected), len(entries))
+	for i := range entries {
+		assert.Equal(expected[i].Hash, entries[i].Hash)
+		assert.True(expected[i].Meta.Created.Equal(entries[i].Meta.Created))
+	}
+
+	// a corrupted proof is detected
+	assert.NoError(os.WriteFile(reopened.path(entries[0].Hash, ".proof"), []byte("corrupted"), 0644))
+	_, err = reopened.Get(entries[0].Hash)
+	assert.True(errors.Is(err, ErrBadRecord))
+}
@@ -1,6 +1,8 @@
 package chainark
 
 import (
+	"fmt"
+
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra"
@@ -15,6 +17,7 @@ type MultiRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2E
 	EndID   LinkageID `gnark:",public"`
 
 	SelfFps []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count   ChainCount                     `gnark:",public"` // optional, enabled iff the unit circuits have one
 
 	FirstVKey    plonk.VerifyingKey[FR, G1El, G2El]
 	FirstProof   plonk.Proof[FR, G1El, G2El]
@@ -27,18 +30,24 @@ type MultiRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2E
 	// constant values passed from outside
 	ValidUnitFps []common_utils.FingerPrintBytes
 	NbSelfFps    int
+	Padding      Padding // see NewPadding
 
-	optimization bool
+	optimizedSize uint64 // see assertProofs
 }
 
 func (c *MultiRecursiveCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginID.Vals[0])
+	if err != nil {
+		return err
+	}
+
 	// verify the first vkey
 	rp := recursiveProof[FR, G1El, G2El, GtEl]{
 		beginID:   c.BeginID,
 		endID:     c.RelayID,
 		nbSelfFps: c.NbSelfFps,
 	}
-	err := rp.assertRelations(api, c.FirstVKey, c.FirstWitness, c.SelfFps, c.ValidUnitFps)
+	err = rp.assertRelations(api, c.FirstVKey, c.FirstWitness, c.SelfFps, c.ValidUnitFps)
 	if err != nil {
 		return err
 	}
@@ -48,48 +57,53 @@ func (c *MultiRecursiveCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) e
 	if err != nil {
 		return err
 	}
-	common_utils.AssertFpInSet[FR](api, secondFp, c.ValidUnitFps)
+	assertFpInSet[FR](api, secondFp, c.ValidUnitFps)
 
-	assertIds[FR](api, c.BeginID, c.RelayID, c.FirstWitness)
-	assertIds[FR](api, c.RelayID, c.EndID, c.SecondWitness)
+	assertIds[FR](api, c.BeginID, c.RelayID, c.FirstWitness.Public)
+	assertIds[FR](api, c.RelayID, c.EndID, c.SecondWitness.Public)
 
-	verifier, err := plonk.NewVerifier[FR, G1El, G2El, GtEl](api)
-	if err != nil {
-		return err
+	if c.Count.Enabled() {
+		assertCountSum[FR](api, c.Count, len(c.BeginID.Vals), c.NbSelfFps, 0, c.FirstWitness.Public, c.SecondWitness.Public)
 	}
 
-	if c.optimization {
-		return verifier.AssertDifferentProofs(c.FirstVKey.BaseVerifyingKey,
-			[]plonk.CircuitVerifyingKey[FR, G1El]{c.FirstVKey.CircuitVerifyingKey, c.SecondVKey.CircuitVerifyingKey},
-			[]frontend.Variable{0, 1},
-			[]plonk.Proof[FR, G1El, G2El]{c.FirstProof, c.SecondProof},
-			[]plonk.Witness[FR]{c.FirstWitness, c.SecondWitness},
-			plonk.WithCompleteArithmetic(),
-		)
-	} else {
-		err = verifier.AssertProof(c.FirstVKey, c.FirstProof, c.FirstWitness, plonk.WithCompleteArithmetic())
-		if err != nil {
-			return err
-		}
-		return verifier.AssertProof(c.SecondVKey, c.SecondProof, c.SecondWitness, plonk.WithCompleteArithmetic())
-	}
+	return assertProofs[FR, G1El, G2El, GtEl](api, c.optimizedSize,
+		[]plonk.VerifyingKey[FR, G1El, G2El]{c.FirstVKey, c.SecondVKey},
+		[]plonk.Proof[FR, G1El, G2El]{c.FirstProof, c.SecondProof},
+		[]plonk.Witness[FR]{c.FirstWitness, c.SecondWitness})
 }
 
 func NewMultiRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
 	nbIdVals, bitsPerIdVal int,
 	ccsUnit constraint.ConstraintSystem,
 	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
-	opt ...bool) *MultiRecursiveCircuit[FR, G1El, G2El, GtEl] {
+	opt ...bool) (*MultiRecursiveCircuit[FR, G1El, G2El, GtEl], error) {
+	return newMultiRecursiveCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, false, opt)
+}
 
-	optm := false
-	if len(opt) != 0 {
-		optm = opt[0]
-	}
+// NewMultiRecursiveCircuitWithCount is the same as NewMultiRecursiveCircuit for unit circuits exposing a ChainCount, see
+// NewMultiUnitCircuitWithCount.
+func NewMultiRecursiveCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	opt ...bool) (*MultiRecursiveCircuit[FR, G1El, G2El, GtEl], error) {
+	return newMultiRecursiveCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, true, opt)
+}
+
+func newMultiRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	counted bool, opt []bool) (*MultiRecursiveCircuit[FR, G1El, G2El, GtEl], error) {
 
 	if nbSelfFps <= 0 {
-		panic("wrong nbSelfFps")
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
 	}
 	selfFps := make([]common_utils.FingerPrint[FR], nbSelfFps)
+	count, err := unitCount(ccsUnit.GetNbPublicVariables(), nbIdVals, nbSelfFps, counted)
+	if err != nil {
+		return nil, err
+	}
 
 	return &MultiRecursiveCircuit[FR, G1El, G2El, GtEl]{
 		BeginID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
@@ -97,6 +111,7 @@ func NewMultiRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT,
 		EndID:   PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
 
 		SelfFps: selfFps,
+		Count:   count,
 
 		FirstVKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccsUnit),
 		FirstProof:   plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit),
@@ -106,10 +121,10 @@ func NewMultiRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT,
 		SecondProof:   plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit),
 		SecondWitness: plonk.PlaceholderWitness[FR](ccsUnit),
 
-		ValidUnitFps: unitFpBytes,
-		NbSelfFps:    nbSelfFps,
-		optimization: optm,
-	}
+		ValidUnitFps:  unitFpBytes,
+		NbSelfFps:     nbSelfFps,
+		optimizedSize: optimizedSize(ccsUnit, opt),
+	}, nil
 }
 
 func NewMultiRecursiveAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
@@ -136,6 +151,22 @@ func NewMultiRecursiveAssignment[FR emulated.FieldParams, G1El algebra.G1Element
 	}
 }
 
+// NewMultiRecursiveAssignmentWithCount is the same as NewMultiRecursiveAssignment for a circuit created by
+// NewMultiRecursiveCircuitWithCount, count being the sum of the counts of both inner proofs.
+func NewMultiRecursiveAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	firstVkey, secondVkey plonk.VerifyingKey[FR, G1El, G2El],
+	firstProof, secondProof plonk.Proof[FR, G1El, G2El],
+	firstWitness, secondWitness plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, endID LinkageID,
+	count uint64,
+) *MultiRecursiveCircuit[FR, G1El, G2El, GtEl] {
+	ret := NewMultiRecursiveAssignment[FR, G1El, G2El, GtEl](
+		firstVkey, secondVkey, firstProof, secondProof, firstWitness, secondWitness, recursiveFps, beginID, relayID, endID)
+	ret.Count = ChainCountOf(count)
+	return ret
+}
+
 type RecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
 	*MultiRecursiveCircuit[FR, G1El, G2El, GtEl]
 }
@@ -149,15 +180,19 @@ func NewRecursiveCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El
 	nbIdVals, bitsPerIdVal int,
 	ccsUnit constraint.ConstraintSystem,
 	unitFpBytes []common_utils.FingerPrintBytes,
-	opt ...bool) *RecursiveCircuit[FR, G1El, G2El, GtEl] {
+	opt ...bool) (*RecursiveCircuit[FR, G1El, G2El, GtEl], error) {
 
-	return &RecursiveCircuit[FR, G1El, G2El, GtEl]{
-		MultiRecursiveCircuit: NewMultiRecursiveCircuit[FR, G1El, G2El, GtEl](
-			nbIdVals, bitsPerIdVal,
-			ccsUnit,
-			unitFpBytes, 1,
-			opt...),
+	multi, err := NewMultiRecursiveCircuit[FR, G1El, G2El, GtEl](
+		nbIdVals, bitsPerIdVal,
+		ccsUnit,
+		unitFpBytes, 1,
+		opt...)
+	if err != nil {
+		return nil, err
 	}
+	return &RecursiveCircuit[FR, G1El, G2El, GtEl]{
+		MultiRecursiveCircuit: multi,
+	}, nil
 }
 
 func NewRecursiveAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
@@ -191,14 +226,26 @@ func (rp *recursiveProof[FR, G1El, G2El, GtEl]) assertRelations(
 	selfFps []common_utils.FingerPrint[FR],
 	unitFps []common_utils.FingerPrintBytes) error {
 
-	// 1. ensure that vkey.FingerPrint matches either one of the Unit VKey Fp, or one of the selfFps
 	vkeyFp, err := common_utils.InCircuitFingerPrint[FR, G1El, G2El](api, &vkey)
 	if err != nil {
 		return err
 	}
+	rp.assertFpRelations(api, vkeyFp, witness.Public, selfFps, unitFps)
+
+	return nil
+}
+
+func (rp *recursiveProof[FR, G1El, G2El, GtEl]) assertFpRelations(
+	api frontend.API,
+	vkeyFp frontend.Variable,
+	witnessValues []emulated.Element[FR],
+	selfFps []common_utils.FingerPrint[FR],
+	unitFps []common_utils.FingerPrintBytes) {
+
+	// 1. ensure that vkey.FingerPrint matches either one of the Unit VKey Fp, or one of the selfFps
 	recursiveFpTest := common_utils.TestFpInFpSet[FR](api, vkeyFp, selfFps)
 
-	unitFpTest := common_utils.TestFpInSet[FR](api, vkeyFp, unitFps)
+	unitFpTest := testFpInSet[FR](api, vkeyFp, unitFps)
 
 	fpTest := api.Or(recursiveFpTest, unitFpTest)
 	api.AssertIsEqual(fpTest, 1)
@@ -207,20 +254,23 @@ func (rp *recursiveProof[FR, G1El, G2El, GtEl]) assertRelations(
 	initialOffset := len(rp.beginID.Vals) + len(rp.endID.Vals)
 	nbFpVars := 1
 
-	setTest := TestRecursiveFps[FR](api, witness, selfFps, initialOffset, nbFpVars, rp.nbSelfFps)
+	setTest := testRecursiveFps[FR](api, witnessValues, selfFps, initialOffset, nbFpVars, rp.nbSelfFps)
 	api.AssertIsEqual(recursiveFpTest, setTest)
-
-	return nil
 }
 
 func TestRecursiveFps[FR emulated.FieldParams](api frontend.API, witness plonk.Witness[FR], selfFps []common_utils.FingerPrint[FR],
 	initialOffset, nbFpVars, nbSelfFps int) frontend.Variable {
+	return testRecursiveFps[FR](api, witness.Public, selfFps, initialOffset, nbFpVars, nbSelfFps)
+}
+
+func testRecursiveFps[FR emulated.FieldParams](api frontend.API, witnessValues []emulated.Element[FR], selfFps []common_utils.FingerPrint[FR],
+	initialOffset, nbFpVars, nbSelfFps int) frontend.Variable {
 
 	test := frontend.Variable(1)
 	for i := 0; i < nbSelfFps; i++ {
 		begin := initialOffset + i*nbFpVars
 		end := begin + nbFpVars
-		t := common_utils.TestFpWitness(api, selfFps[i], witness.Public[begin:end])
+		t := common_utils.TestFpWitness(api, selfFps[i], witnessValues[begin:end])
 		test = api.And(test, t)
 	}
 	return test
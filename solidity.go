@@ -0,0 +1,153 @@
+package chainark
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"text/template"
+
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/backend/witness"
+	"golang.org/x/crypto/sha3"
+)
+
+// the wrapper entry point, calldata built by SolidityCalldata targets this function
+const verifyChainProofSignature = "verifyChainProof(bytes,uint256[])"
+
+/**
+ * ExportSolidity writes the PLONK verifier contract for vk, which should be the verifying key of the final wrapping
+ * circuit built upon Verifier. Only BN254 is supported. Note that proofs to be verified on chain must be generated
+ * with solidity.WithProverTargetSolidityVerifier(backend.PLONK).
+ */
+func ExportSolidity(vk native_plonk.VerifyingKey, w io.Writer, opts ...solidity.ExportOption) error {
+	svk, ok := vk.(solidity.VerifyingKey)
+	if !ok {
+		return fmt.Errorf("verifying key of type %T could not be exported to solidity", vk)
+	}
+	return svk.ExportSolidity(w, opts...)
+}
+
+/**
+ * ExportSolidityWrapper writes a thin contract calling the PLONK verifier exported by ExportSolidity, then decoding
+ * BeginID and EndID from the public inputs. The wrapping circuit is expected to expose BeginID and EndID, each of
+ * nbIdVars variables of bitsPerVar bits, as its first public inputs, just like LinkageIDFromBytes lays them out.
+ */
+func ExportSolidityWrapper(w io.Writer, nbIdVars, bitsPerVar int, opts ...solidity.ExportOption) error {
+	if nbIdVars <= 0 || bitsPerVar <= 0 || bitsPerVar > 253 {
+		return fmt.Errorf("wrong nbIdVars %v or bitsPerVar %v", nbIdVars, bitsPerVar)
+	}
+	cfg, err := solidity.NewExportConfig(opts...)
+	if err != nil {
+		return err
+	}
+
+	t, err := template.New("wrapper").Parse(tmplSolidityWrapper)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, struct {
+		Pragma      string
+		NbIdVars    int
+		BitsPerVar  int
+		BytesPerVar int
+	}{
+		Pragma:      cfg.PragmaVersion,
+		NbIdVars:    nbIdVars,
+		BitsPerVar:  bitsPerVar,
+		BytesPerVar: (bitsPerVar + 7) / 8,
+	})
+}
+
+// SolidityProof returns the proof bytes and the public inputs as expected by the exported PLONK verifier.
+func SolidityProof(proof native_plonk.Proof, pubWitness witness.Witness) ([]byte, []*big.Int, error) {
+	p, ok := proof.(*plonk_bn254.Proof)
+	if !ok {
+		return nil, nil, fmt.Errorf("proof of type %T could not be verified in solidity", proof)
+	}
+	inputs, err := witnessValues(pubWitness)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.MarshalSolidity(), inputs, nil
+}
+
+// SolidityCalldata returns the ABI encoded calldata to call verifyChainProof on the wrapper contract.
+func SolidityCalldata(proof native_plonk.Proof, pubWitness witness.Witness) ([]byte, error) {
+	proofBytes, inputs, err := SolidityProof(proof, pubWitness)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(verifyChainProofSignature))
+	selector := h.Sum(nil)[:4]
+
+	paddedLen := (len(proofBytes) + 31) / 32 * 32
+
+	ret := make([]byte, 0, 4+32*4+paddedLen+32*len(inputs))
+	ret = append(ret, selector...)
+	ret = append(ret, abiWord(big.NewInt(2*32))...)                  // offset of proof
+	ret = append(ret, abiWord(big.NewInt(int64(3*32+paddedLen)))...) // offset of inputs
+	ret = append(ret, abiWord(big.NewInt(int64(len(proofBytes))))...)
+	ret = append(ret, proofBytes...)
+	ret = append(ret, make([]byte, paddedLen-len(proofBytes))...)
+	ret = append(ret, abiWord(big.NewInt(int64(len(inputs))))...)
+	for i := 0; i < len(inputs); i++ {
+		ret = append(ret, abiWord(inputs[i])...)
+	}
+
+	return ret, nil
+}
+
+func abiWord(v *big.Int) []byte {
+	return v.FillBytes(make([]byte, 32))
+}
+
+const tmplSolidityWrapper = `// SPDX-License-Identifier: MIT
+
+pragma solidity {{ .Pragma }};
+
+interface IPlonkVerifier {
+  function Verify(bytes calldata proof, uint256[] calldata public_inputs) external view returns (bool success);
+}
+
+/// @notice verifies a chainark proof, then returns the begin and end IDs of the proved chain.
+contract ChainarkVerifier {
+  uint256 public constant NB_ID_VARS = {{ .NbIdVars }};
+  uint256 public constant BITS_PER_VAR = {{ .BitsPerVar }};
+  uint256 public constant BYTES_PER_VAR = {{ .BytesPerVar }};
+
+  IPlonkVerifier public immutable verifier;
+
+  constructor(address plonkVerifier) {
+    verifier = IPlonkVerifier(plonkVerifier);
+  }
+
+  function verifyChainProof(bytes calldata proof, uint256[] calldata publicInputs)
+  external view returns (bytes memory beginId, bytes memory endId) {
+    require(verifier.Verify(proof, publicInputs), "chainark: invalid proof");
+    return decodeIds(publicInputs);
+  }
+
+  function decodeIds(uint256[] calldata publicInputs)
+  public pure returns (bytes memory beginId, bytes memory endId) {
+    require(publicInputs.length >= 2 * NB_ID_VARS, "chainark: too few public inputs");
+    beginId = decodeId(publicInputs, 0);
+    endId = decodeId(publicInputs, NB_ID_VARS);
+  }
+
+  function decodeId(uint256[] calldata publicInputs, uint256 offset)
+  internal pure returns (bytes memory id) {
+    id = new bytes(NB_ID_VARS * BYTES_PER_VAR);
+    for (uint256 i = 0; i < NB_ID_VARS; i++) {
+      uint256 v = publicInputs[offset + i];
+      require(v >> BITS_PER_VAR == 0, "chainark: id var out of range");
+      for (uint256 j = 0; j < BYTES_PER_VAR; j++) {
+        id[i * BYTES_PER_VAR + j] = bytes1(uint8(v >> (8 * (BYTES_PER_VAR - 1 - j))));
+      }
+    }
+  }
+}
+`
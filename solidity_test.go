@@ -0,0 +1,50 @@
+package chainark
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestSolidityExport(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var wrapper bytes.Buffer
+	err := ExportSolidityWrapper(&wrapper, 2, 128)
+	assert.NoError(err)
+	assert.True(strings.Contains(wrapper.String(), "NB_ID_VARS = 2;"))
+	assert.True(strings.Contains(wrapper.String(), "BYTES_PER_VAR = 16;"))
+
+	unit := setupTestUnit(assert, 1, 0)
+
+	var verifier bytes.Buffer
+	err = ExportSolidity(unit.vk, &verifier)
+	assert.NoError(err)
+	assert.True(strings.Contains(verifier.String(), "contract PlonkVerifier"))
+
+	witness, err := frontend.NewWitness(unit.link(0), ecc.BN254.ScalarField())
+	assert.NoError(err)
+	pubWitness, err := witness.Public()
+	assert.NoError(err)
+	proof, err := native_plonk.Prove(unit.ccs, unit.pk, witness, solidity.WithProverTargetSolidityVerifier(backend.PLONK))
+	assert.NoError(err)
+
+	proofBytes, inputs, err := SolidityProof(proof, pubWitness)
+	assert.NoError(err)
+	assert.Equal(5, len(inputs))
+	assert.Equal(0, new(big.Int).SetBytes(unit.ids[0][:16]).Cmp(inputs[0]))
+
+	calldata, err := SolidityCalldata(proof, pubWitness)
+	assert.NoError(err)
+	paddedLen := (len(proofBytes) + 31) / 32 * 32
+	assert.Equal(4+3*32+paddedLen+32+32*len(inputs), len(calldata))
+	assert.Equal(int64(len(proofBytes)), new(big.Int).SetBytes(calldata[4+64:4+96]).Int64())
+}
@@ -0,0 +1,176 @@
+package chainark
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// TreeCircuit merges two adjacent chain segments BeginID -> RelayID and RelayID -> EndID, each of which could be
+// proved by either a unit proof or an aggregated proof. Disjoint segments could then be proved in parallel and
+// merged in log depth. The fingerprint of the TreeCircuit itself must be one of the SelfFps, and so must be the
+// fingerprints of all other recursive circuits whose proofs are to be merged.
+type TreeCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	BeginID LinkageID `gnark:",public"`
+	RelayID LinkageID
+	EndID   LinkageID `gnark:",public"`
+
+	SelfFps []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count   ChainCount                     `gnark:",public"` // optional, enabled iff the unit circuits have one
+
+	LeftVKey    plonk.VerifyingKey[FR, G1El, G2El]
+	LeftProof   plonk.Proof[FR, G1El, G2El]
+	LeftWitness plonk.Witness[FR]
+
+	RightVKey    plonk.VerifyingKey[FR, G1El, G2El]
+	RightProof   plonk.Proof[FR, G1El, G2El]
+	RightWitness plonk.Witness[FR]
+
+	// constant values passed from outside
+	ValidUnitFps []common_utils.FingerPrintBytes
+	NbSelfFps    int
+	Padding      Padding // see NewPadding
+
+	optimizedSize uint64 // see assertProofs
+}
+
+func (c *TreeCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginID.Vals[0])
+	if err != nil {
+		return err
+	}
+
+	// verify the left vkey
+	left := recursiveProof[FR, G1El, G2El, GtEl]{
+		beginID:   c.BeginID,
+		endID:     c.RelayID,
+		nbSelfFps: c.NbSelfFps,
+	}
+	err = left.assertRelations(api, c.LeftVKey, c.LeftWitness, c.SelfFps, c.ValidUnitFps)
+	if err != nil {
+		return err
+	}
+
+	// verify the right vkey
+	right := recursiveProof[FR, G1El, G2El, GtEl]{
+		beginID:   c.RelayID,
+		endID:     c.EndID,
+		nbSelfFps: c.NbSelfFps,
+	}
+	err = right.assertRelations(api, c.RightVKey, c.RightWitness, c.SelfFps, c.ValidUnitFps)
+	if err != nil {
+		return err
+	}
+
+	assertIds[FR](api, c.BeginID, c.RelayID, c.LeftWitness.Public)
+	assertIds[FR](api, c.RelayID, c.EndID, c.RightWitness.Public)
+
+	if c.Count.Enabled() {
+		assertCountSum[FR](api, c.Count, len(c.BeginID.Vals), c.NbSelfFps, 0, c.LeftWitness.Public, c.RightWitness.Public)
+	}
+
+	return assertProofs[FR, G1El, G2El, GtEl](api, c.optimizedSize,
+		[]plonk.VerifyingKey[FR, G1El, G2El]{c.LeftVKey, c.RightVKey},
+		[]plonk.Proof[FR, G1El, G2El]{c.LeftProof, c.RightProof},
+		[]plonk.Witness[FR]{c.LeftWitness, c.RightWitness})
+}
+
+func NewTreeCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	opt ...bool) (*TreeCircuit[FR, G1El, G2El, GtEl], error) {
+	return newTreeCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, false, opt)
+}
+
+// NewTreeCircuitWithCount is the same as NewTreeCircuit for unit circuits exposing a ChainCount, see
+// NewMultiUnitCircuitWithCount.
+func NewTreeCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	opt ...bool) (*TreeCircuit[FR, G1El, G2El, GtEl], error) {
+	return newTreeCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, true, opt)
+}
+
+func newTreeCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	counted bool, opt []bool) (*TreeCircuit[FR, G1El, G2El, GtEl], error) {
+
+	if nbSelfFps <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
+	}
+	selfFps := make([]common_utils.FingerPrint[FR], nbSelfFps)
+	count, err := unitCount(ccsUnit.GetNbPublicVariables(), nbIdVals, nbSelfFps, counted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TreeCircuit[FR, G1El, G2El, GtEl]{
+		BeginID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		RelayID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		EndID:   PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+
+		SelfFps: selfFps,
+		Count:   count,
+
+		LeftVKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccsUnit),
+		LeftProof:   plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit),
+		LeftWitness: plonk.PlaceholderWitness[FR](ccsUnit),
+
+		RightVKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccsUnit),
+		RightProof:   plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit),
+		RightWitness: plonk.PlaceholderWitness[FR](ccsUnit),
+
+		ValidUnitFps:  unitFpBytes,
+		NbSelfFps:     nbSelfFps,
+		optimizedSize: optimizedSize(ccsUnit, opt),
+	}, nil
+}
+
+func NewTreeAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	leftVkey, rightVkey plonk.VerifyingKey[FR, G1El, G2El],
+	leftProof, rightProof plonk.Proof[FR, G1El, G2El],
+	leftWitness, rightWitness plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, endID LinkageID,
+) *TreeCircuit[FR, G1El, G2El, GtEl] {
+	return &TreeCircuit[FR, G1El, G2El, GtEl]{
+		BeginID: beginID,
+		RelayID: relayID,
+		EndID:   endID,
+
+		SelfFps: recursiveFps,
+
+		LeftVKey:    leftVkey,
+		LeftProof:   leftProof,
+		LeftWitness: leftWitness,
+
+		RightVKey:    rightVkey,
+		RightProof:   rightProof,
+		RightWitness: rightWitness,
+	}
+}
+
+// NewTreeAssignmentWithCount is the same as NewTreeAssignment for a circuit created by NewTreeCircuitWithCount, count
+// being the sum of the counts of the left and right proofs.
+func NewTreeAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	leftVkey, rightVkey plonk.VerifyingKey[FR, G1El, G2El],
+	leftProof, rightProof plonk.Proof[FR, G1El, G2El],
+	leftWitness, rightWitness plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, endID LinkageID,
+	count uint64,
+) *TreeCircuit[FR, G1El, G2El, GtEl] {
+	ret := NewTreeAssignment[FR, G1El, G2El, GtEl](
+		leftVkey, rightVkey, leftProof, rightProof, leftWitness, rightWitness, recursiveFps, beginID, relayID, endID)
+	ret.Count = ChainCountOf(count)
+	return ret
+}
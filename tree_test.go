@@ -0,0 +1,69 @@
+package chainark
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// same shape as testUnitCircuit, standing for a recursive circuit when its proofs carry its own fingerprint
+type testSelfCircuit struct {
+	*MultiUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+}
+
+func (c *testSelfCircuit) Define(api frontend.API) error {
+	x := api.Mul(c.BeginID.Vals[0], c.EndID.Vals[0])
+	y := api.Add(x, c.BeginID.Vals[1], 11)
+	api.AssertIsDifferent(api.Sub(api.Mul(y, 3), c.EndID.Vals[1]), 0)
+	return c.MultiUnit.Define(api)
+}
+
+func TestTree(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit(assert, 1, 0)
+	self := newTestUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](assert,
+		func(u *MultiUnit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]) frontend.Circuit {
+			return &testSelfCircuit{MultiUnit: u}
+		}, 1, 0)
+	ids := unit.ids
+
+	circuit, err := NewTreeCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		2, 128, unit.ccs, []common_utils.FingerPrintBytes{unit.fp}, 1)
+	assert.NoError(err)
+
+	type innerProof = testInnerProof[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine]
+	assign := func(left, right innerProof, relayID []byte) *TreeCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl] {
+		return NewTreeAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+			left.vk, right.vk,
+			left.proof, right.proof,
+			left.witness, right.witness,
+			[]common_utils.FingerPrint[sw_bn254.ScalarField]{common_utils.FingerPrintFromBytes[sw_bn254.ScalarField](self.fp)},
+			LinkageIDFromBytes(ids[0], 128), LinkageIDFromBytes(relayID, 128), LinkageIDFromBytes(ids[2], 128),
+		)
+	}
+
+	// unit + unit
+	leftUnit := unit.prove(assert, unit.link(0))
+	rightUnit := unit.prove(assert, unit.link(1))
+	err = test.IsSolved(circuit, assign(leftUnit, rightUnit, ids[1]), ecc.BN254.ScalarField())
+	assert.NoError(err)
+
+	// a mismatched relay id
+	err = test.IsSolved(circuit, assign(leftUnit, rightUnit, ids[2]), ecc.BN254.ScalarField())
+	assert.Error(err)
+
+	// self + self, both carrying the SelfFps of the tree circuit
+	leftSelf := self.prove(assert, self.link(0, self.fp))
+	rightSelf := self.prove(assert, self.link(1, self.fp))
+	err = test.IsSolved(circuit, assign(leftSelf, rightSelf, ids[1]), ecc.BN254.ScalarField())
+	assert.NoError(err)
+
+	// an inner recursive proof carrying foreign SelfFps
+	foreign := self.prove(assert, self.link(1, unit.fp))
+	err = test.IsSolved(circuit, assign(leftSelf, foreign, ids[1]), ecc.BN254.ScalarField())
+	assert.Error(err)
+}
@@ -0,0 +1,167 @@
+package chainark
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+	"github.com/consensys/gnark-crypto/hash"
+	native_plonk "github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bw6761"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/std/commitments/kzg"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+/**
+ * Besides BN254 in BN254, chainark could run on the BLS12-377 / BW6-761 2-chain: unit circuits on BLS12-377, verified
+ * natively (without field emulation) by recursive or hybrid circuits on BW6-761, instantiated with the sw_bls12377
+ * types. A BW6-761 proof could not be verified natively on BW6-761, so the inner proofs on the 2-chain are always unit
+ * proofs, typically aggregated by a KaryRecursiveCircuit. The BW6-761 proof could then be wrapped on BN254 by a
+ * Verifier instantiated with the sw_bw6761 types.
+ *
+ * Fingerprints are computed in the field of the circuit verifying the key, which is larger than FR on the 2-chain. Use
+ * FingerPrintOf instead of common_utils.FingerPrintFromBytes to assign them.
+ */
+
+// UnsafeFingerPrintFromVk extends common_utils.UnsafeFingerPrintFromVk to BLS12-377 keys verified on BW6-761, and
+// BW6-761 keys verified on BN254.
+func UnsafeFingerPrintFromVk[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	vk native_plonk.VerifyingKey) ([]byte, error) {
+	var h hash.Hash
+	var g1 G1El
+	switch any(&g1).(type) {
+	case *sw_bls12377.G1Affine:
+		h = hash.MIMC_BW6_761
+	case *sw_bw6761.G1Affine:
+		h = hash.MIMC_BN254
+	default:
+		return common_utils.UnsafeFingerPrintFromVk[FR, G1El, G2El, GtEl](vk)
+	}
+
+	circuitVk, err := plonk.ValueOfVerifyingKey[FR, G1El, G2El](vk)
+	if err != nil {
+		return nil, err
+	}
+	values, err := vkValues[FR, G1El, G2El](&circuitVk)
+	if err != nil {
+		return nil, err
+	}
+
+	// each value is written as a full block, so that zero values are hashed the same way in and out of circuit
+	mimc := h.New()
+	for i := 0; i < len(values); i++ {
+		mimc.Write(values[i].FillBytes(make([]byte, mimc.BlockSize())))
+	}
+	return mimc.Sum(nil), nil
+}
+
+// FingerPrintOf is the same as common_utils.FingerPrintFromBytes, except that the fingerprint is not required to fit
+// in FR, as it is the case on the 2-chain.
+func FingerPrintOf[FR emulated.FieldParams](data common_utils.FingerPrintBytes) common_utils.FingerPrint[FR] {
+	return common_utils.FingerPrint[FR]{
+		Val: new(big.Int).SetBytes(data),
+	}
+}
+
+// FingerPrintsOf returns the fingerprints of data with FingerPrintOf, e.g. to assign the SelfFps of a circuit.
+func FingerPrintsOf[FR emulated.FieldParams](data []common_utils.FingerPrintBytes) []common_utils.FingerPrint[FR] {
+	ret := make([]common_utils.FingerPrint[FR], len(data))
+	for i := 0; i < len(data); i++ {
+		ret[i] = FingerPrintOf[FR](data[i])
+	}
+	return ret
+}
+
+// outerField returns the field of the circuits verifying proofs over FR, which determines the native prover and
+// verifier options of these proofs.
+func outerField[FR emulated.FieldParams]() *big.Int {
+	var fr FR
+	switch {
+	case fr.Modulus().Cmp(ecc.BLS12_377.ScalarField()) == 0:
+		return ecc.BW6_761.ScalarField()
+	case fr.Modulus().Cmp(ecc.BW6_761.ScalarField()) == 0:
+		return ecc.BN254.ScalarField()
+	default:
+		return fr.Modulus()
+	}
+}
+
+// outerHash returns the MiMC hash of the field of the circuits verifying proofs over FR, as outerField.
+func outerHash[FR emulated.FieldParams]() (hash.Hash, error) {
+	field := outerField[FR]()
+	for _, h := range []struct {
+		curve ecc.ID
+		hash  hash.Hash
+	}{
+		{ecc.BN254, hash.MIMC_BN254},
+		{ecc.BW6_761, hash.MIMC_BW6_761},
+		{ecc.BLS12_377, hash.MIMC_BLS12_377},
+		{ecc.BLS12_381, hash.MIMC_BLS12_381},
+	} {
+		if field.Cmp(h.curve.ScalarField()) == 0 {
+			return h.hash, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no MiMC hash over %v", ErrUnsupportedCurve, field)
+}
+
+// vkValues lists the values of vk in the same order as common_utils.InCircuitFingerPrint writes them.
+func vkValues[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT](
+	vk *plonk.VerifyingKey[FR, G1El, G2El]) ([]*big.Int, error) {
+	vars := []any{vk.BaseVerifyingKey.NbPublicVariables, vk.CircuitVerifyingKey.Size}
+	for i := 0; i < len(vk.CircuitVerifyingKey.Generator.Limbs); i++ {
+		vars = append(vars, vk.CircuitVerifyingKey.Generator.Limbs[i])
+	}
+
+	comms := make([]kzg.Commitment[G1El], 0)
+	comms = append(comms, vk.CircuitVerifyingKey.S[:]...)
+	comms = append(comms, vk.CircuitVerifyingKey.Ql)
+	comms = append(comms, vk.CircuitVerifyingKey.Qr)
+	comms = append(comms, vk.CircuitVerifyingKey.Qm)
+	comms = append(comms, vk.CircuitVerifyingKey.Qo)
+	comms = append(comms, vk.CircuitVerifyingKey.Qk)
+	comms = append(comms, vk.CircuitVerifyingKey.Qcp[:]...)
+
+	for _, comm := range comms {
+		el := comm.G1El
+		switch r := any(&el).(type) {
+		case *sw_bls12377.G1Affine:
+			vars = append(vars, r.X, r.Y)
+		case *sw_bw6761.G1Affine:
+			for i := 0; i < len(r.X.Limbs); i++ {
+				vars = append(vars, r.X.Limbs[i])
+			}
+			for i := 0; i < len(r.Y.Limbs); i++ {
+				vars = append(vars, r.Y.Limbs[i])
+			}
+		default:
+			return nil, fmt.Errorf("unknown parametric type %T", r)
+		}
+	}
+
+	for i := 0; i < len(vk.CircuitVerifyingKey.CommitmentConstraintIndexes); i++ {
+		vars = append(vars, vk.CircuitVerifyingKey.CommitmentConstraintIndexes[i])
+	}
+
+	ret := make([]*big.Int, len(vars))
+	for i := 0; i < len(vars); i++ {
+		switch v := vars[i].(type) {
+		case *big.Int:
+			ret[i] = v
+		case uint64:
+			ret[i] = new(big.Int).SetUint64(v)
+		case int:
+			ret[i] = big.NewInt(int64(v))
+		case fr_bw6761.Element:
+			ret[i] = v.BigInt(new(big.Int))
+		default:
+			return nil, fmt.Errorf("unexpected vkey value of type %T", v)
+		}
+	}
+	return ret, nil
+}
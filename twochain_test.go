@@ -0,0 +1,50 @@
+package chainark
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// same as testUnitCircuit, on BLS12-377
+type testUnitCircuit2Chain struct {
+	*MultiUnit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]
+}
+
+func (c *testUnitCircuit2Chain) Define(api frontend.API) error {
+	x := api.Mul(c.BeginID.Vals[0], c.EndID.Vals[0])
+	y := api.Add(x, c.BeginID.Vals[1], 7)
+	api.AssertIsDifferent(api.Sub(api.Mul(y, 3), c.EndID.Vals[1]), 0)
+	return c.MultiUnit.Define(api)
+}
+
+func TestRecursive2Chain(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit2Chain(assert, 0)
+	first := unit.prove(assert, unit.link(0))
+	second := unit.prove(assert, unit.link(1))
+
+	circuit, err := NewMultiRecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+		2, 128, unit.ccs, []common_utils.FingerPrintBytes{unit.fp}, 1)
+	assert.NoError(err)
+
+	assign := func(endID []byte) *MultiRecursiveCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT] {
+		return NewMultiRecursiveAssignment[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			first.vk, second.vk,
+			first.proof, second.proof,
+			first.witness, second.witness,
+			[]common_utils.FingerPrint[sw_bls12377.ScalarField]{unit.recursiveFp()},
+			LinkageIDFromBytes(unit.ids[0], 128), LinkageIDFromBytes(unit.ids[1], 128), LinkageIDFromBytes(endID, 128),
+		)
+	}
+
+	err = test.IsSolved(circuit, assign(unit.ids[2]), ecc.BW6_761.ScalarField())
+	assert.NoError(err)
+
+	err = test.IsSolved(circuit, assign(unit.ids[1]), ecc.BW6_761.ScalarField())
+	assert.Error(err)
+}
@@ -1,8 +1,12 @@
 package chainark
 
 import (
+	"fmt"
+	"math/big"
+
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/rangecheck"
 
 	common_utils "github.com/lightec-xyz/common/utils"
 )
@@ -10,6 +14,7 @@ import (
 type LinkageID struct {
 	Vals       []frontend.Variable
 	BitsPerVar int
+	NbBits     int // total number of bits if the top value is partial, 0 otherwise
 }
 
 func PlaceholderLinkageID(nbEles, bitsPerVar int) LinkageID {
@@ -19,33 +24,127 @@ func PlaceholderLinkageID(nbEles, bitsPerVar int) LinkageID {
 	}
 }
 
-func (id LinkageID) AssertIsEqual(api frontend.API, other LinkageID) {
-	if id.BitsPerVar != other.BitsPerVar {
-		panic("BitsPerVar not equal")
-	}
-	if len(id.Vals) != len(other.Vals) {
-		panic("Vals count not eual")
+func (id LinkageID) AssertIsEqual(api frontend.API, other LinkageID) error {
+	if err := id.checkShape(other); err != nil {
+		return err
 	}
 
 	for i := 0; i < len(id.Vals); i++ {
 		api.AssertIsEqual(id.Vals[i], other.Vals[i])
 	}
+	return nil
+}
+
+func (id LinkageID) IsEqual(api frontend.API, other LinkageID) (frontend.Variable, error) {
+	if err := id.checkShape(other); err != nil {
+		return nil, err
+	}
+	return common_utils.AreVarsEquals(api, id.Vals, other.Vals), nil
 }
 
-func (id LinkageID) IsEqual(api frontend.API, other LinkageID) frontend.Variable {
+func (id LinkageID) checkShape(other LinkageID) error {
 	if id.BitsPerVar != other.BitsPerVar {
-		panic("BitsPerVar not equal")
+		return fmt.Errorf("%w: BitsPerVar %v vs %v", ErrIDShapeMismatch, id.BitsPerVar, other.BitsPerVar)
+	}
+	if len(id.Vals) != len(other.Vals) {
+		return fmt.Errorf("%w: %v vals vs %v", ErrIDShapeMismatch, len(id.Vals), len(other.Vals))
 	}
-	return common_utils.AreVarsEquals(api, id.Vals, other.Vals)
+	return nil
 }
 
 type LinkageIDBytes []byte
 
+/**
+ * The values of a LinkageID are laid out in its bytes from the most significant one. If BitsPerVar is a multiple of 8
+ * and the number of bytes a multiple of the bytes per value, each value takes its bytes, as common_utils.ValsFromBytes
+ * does. Otherwise the id has a partial top value: each value takes BitsPerVar bits but the top one, taking the remaining
+ * bits, e.g. a 160-bit address with 128-bit values has a top value of 32 bits, and a 192-bit id with 60-bit values a top
+ * value of 12 bits, and NbBits is set to the total number of bits. NbBits could
+ * also be set with PlaceholderLinkageIDOfBits for ids whose number of bits is not a multiple of 8, such as 254-bit
+ * field elements. A partial top value is range checked by ToU8s and AssertIsInRange.
+ */
+
+// wholeBytesLayout tells if each value of an id of nbBytes bytes takes its bytes, without a partial top value.
+func wholeBytesLayout(nbBytes, bitsPerVar int) bool {
+	return bitsPerVar%8 == 0 && nbBytes%(bitsPerVar/8) == 0
+}
+
+// idLayout returns the number of bits taken by each value in the encoding of an id of nbBytes bytes, the top one first.
+func idLayout(nbBytes, bitsPerVar int) []int {
+	if wholeBytesLayout(nbBytes, bitsPerVar) {
+		ret := make([]int, nbBytes*8/bitsPerVar)
+		for i := range ret {
+			ret[i] = bitsPerVar
+		}
+		return ret
+	}
+
+	nbBits := nbBytes * 8
+	ret := make([]int, (nbBits+bitsPerVar-1)/bitsPerVar)
+	for i := range ret {
+		ret[i] = bitsPerVar
+	}
+	ret[0] = nbBits - (len(ret)-1)*bitsPerVar
+	return ret
+}
+
+// splitIDBytes returns the values of the id of bytes data, the top one first.
+func splitIDBytes(data []byte, bitsPerVar int) []*big.Int {
+	layout := idLayout(len(data), bitsPerVar)
+	x := new(big.Int).SetBytes(data)
+	ret := make([]*big.Int, len(layout))
+	for i := len(layout) - 1; i >= 0; i-- {
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(layout[i])), big.NewInt(1))
+		ret[i] = new(big.Int).And(x, mask)
+		x.Rsh(x, uint(layout[i]))
+	}
+	return ret
+}
+
+// joinIDValues is the inverse of splitIDBytes, returning the id of nbBytes bytes of values, the top one first.
+func joinIDValues(values []*big.Int, nbBytes, bitsPerVar int) (LinkageIDBytes, error) {
+	layout := idLayout(nbBytes, bitsPerVar)
+	if len(values) != len(layout) {
+		return nil, fmt.Errorf("%w: %v id values, expecting %v", ErrWitnessShape, len(values), len(layout))
+	}
+	x := new(big.Int)
+	for i := 0; i < len(layout); i++ {
+		if values[i].BitLen() > layout[i] {
+			return nil, fmt.Errorf("%w: id value exceeds %v bits", ErrWitnessShape, layout[i])
+		}
+		x.Lsh(x, uint(layout[i])).Or(x, values[i])
+	}
+	return x.FillBytes(make([]byte, nbBytes)), nil
+}
+
+// PlaceholderLinkageIDOfBits returns the placeholder of an id of nbBits bits, laid out as LinkageIDFromBytes does.
+func PlaceholderLinkageIDOfBits(nbBits, bitsPerVar int) LinkageID {
+	if bitsPerVar%8 == 0 && nbBits%bitsPerVar == 0 {
+		return PlaceholderLinkageID(nbBits/bitsPerVar, bitsPerVar)
+	}
+	id := PlaceholderLinkageID((nbBits+bitsPerVar-1)/bitsPerVar, bitsPerVar)
+	id.NbBits = nbBits
+	return id
+}
+
 func LinkageIDFromBytes(data LinkageIDBytes, bitsPerVar int) LinkageID {
-	return LinkageID{
-		Vals:       common_utils.ValsFromBytes(data, bitsPerVar),
+	if wholeBytesLayout(len(data), bitsPerVar) {
+		return LinkageID{
+			Vals:       common_utils.ValsFromBytes(data, bitsPerVar),
+			BitsPerVar: bitsPerVar,
+		}
+	}
+
+	vals := splitIDBytes(data, bitsPerVar)
+	ret := LinkageID{
+		Vals:       make([]frontend.Variable, len(vals)),
 		BitsPerVar: bitsPerVar,
+		NbBits:     len(data) * 8,
+	}
+	for i := 0; i < len(vals); i++ {
+		ret.Vals[i] = vals[i]
 	}
+	return ret
 }
 
 func LinkageIDFromU8s(api frontend.API, data []uints.U8, bitsPerVar int) LinkageID {
@@ -58,23 +157,48 @@ func LinkageIDFromU8s(api frontend.API, data []uints.U8, bitsPerVar int) Linkage
 	}
 
 	vals := make([]frontend.Variable, 0)
-	for i := len(bits); i > 0; i -= bitsPerVar { // reverse order in vars
-		val := api.FromBinary(bits[i-bitsPerVar : i]...)
+	i := len(bits)
+	for _, nbBits := range idLayout(n, bitsPerVar) { // reverse order in vars
+		val := api.FromBinary(bits[i-nbBits : i]...)
 		vals = append(vals, val)
+		i -= nbBits
 	}
 
-	return LinkageID{
+	ret := LinkageID{
 		Vals:       vals,
 		BitsPerVar: bitsPerVar,
 	}
+	if !wholeBytesLayout(n, bitsPerVar) {
+		ret.NbBits = n * 8
+	}
+	return ret
+}
+
+// valBits returns the number of bits of each value of id, the top one first.
+func (id LinkageID) valBits() []int {
+	ret := make([]int, len(id.Vals))
+	for i := range ret {
+		ret[i] = id.BitsPerVar
+	}
+	if id.NbBits != 0 && len(ret) != 0 {
+		ret[0] = id.NbBits - (len(ret)-1)*id.BitsPerVar
+	}
+	return ret
 }
 
 func (id LinkageID) ToU8s(api frontend.API) []uints.U8 {
 	n := len(id.Vals)
-	bits := make([]frontend.Variable, n*id.BitsPerVar)
-	for i := 0; i < n; i++ {
-		bs := api.ToBinary(id.Vals[n-1-i], id.BitsPerVar) // reverse order in vars
-		copy(bits[i*id.BitsPerVar:(i+1)*id.BitsPerVar], bs)
+	valBits := id.valBits()
+	bits := make([]frontend.Variable, 0)
+	for i := n - 1; i >= 0; i-- { // reverse order in vars
+		bs := api.ToBinary(id.Vals[i], valBits[i])
+		bits = append(bits, bs...)
+		for id.NbBits == 0 && len(bits)%8 != 0 { // each value takes whole bytes
+			bits = append(bits, 0)
+		}
+	}
+	for len(bits)%8 != 0 { // the partial top value is padded
+		bits = append(bits, 0)
 	}
 
 	ret := make([]uints.U8, 0)
@@ -85,3 +209,13 @@ func (id LinkageID) ToU8s(api frontend.API) []uints.U8 {
 
 	return ret
 }
+
+// AssertIsInRange asserts that each value of id fits in its number of bits, less than BitsPerVar for a partial top
+// value.
+func (id LinkageID) AssertIsInRange(api frontend.API) {
+	rcheck := rangecheck.New(api)
+	valBits := id.valBits()
+	for i := 0; i < len(id.Vals); i++ {
+		rcheck.Check(id.Vals[i], valBits[i])
+	}
+}
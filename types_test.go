@@ -2,10 +2,13 @@ package chainark
 
 import (
 	"encoding/hex"
+	"errors"
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
 	"github.com/consensys/gnark/std/math/uints"
 	"github.com/consensys/gnark/test"
 )
@@ -17,9 +20,15 @@ type IDCircuit struct {
 
 func (c *IDCircuit) Define(api frontend.API) error {
 	fromU8s := LinkageIDFromU8s(api, uints.NewU8Array(c.Bytes), 128) // from U8s
-	fromU8s.AssertIsEqual(api, c.FromBytes)
+	err := fromU8s.AssertIsEqual(api, c.FromBytes)
+	if err != nil {
+		return err
+	}
 
-	t := fromU8s.IsEqual(api, c.FromBytes)
+	t, err := fromU8s.IsEqual(api, c.FromBytes)
+	if err != nil {
+		return err
+	}
 	api.AssertIsEqual(t, 1)
 
 	u8s := fromU8s.ToU8s(api) // to U8s
@@ -48,3 +57,123 @@ func TestLinkageID(t *testing.T) {
 	err = test.IsSolved(&circuit, &witness, ecc.BN254.ScalarField())
 	assert.NoError(err)
 }
+
+type IDShapeCircuit struct {
+	A LinkageID
+	B LinkageID
+}
+
+func (c *IDShapeCircuit) Define(api frontend.API) error {
+	return c.A.AssertIsEqual(api, c.B)
+}
+
+func TestLinkageIDShapeMismatch(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, b := range []LinkageID{PlaceholderLinkageID(1, 256), PlaceholderLinkageID(4, 128)} {
+		circuit := IDShapeCircuit{
+			A: PlaceholderLinkageID(2, 128),
+			B: b,
+		}
+		_, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+		assert.True(errors.Is(err, ErrIDShapeMismatch))
+	}
+
+	_, err := NewMultiRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](2, 128, nil, nil, 0)
+	assert.True(errors.Is(err, ErrBadSelfFpCount))
+	for _, nbProofs := range []int{0, 1} {
+		_, err = NewKaryRecursiveCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](2, 128, nil, nil, 1, nbProofs)
+		assert.True(errors.Is(err, ErrBadProofCount))
+	}
+	_, err = NewVerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](nil, nil, 2, 1, 1)
+	assert.True(errors.Is(err, ErrBadSelfFpCount))
+}
+
+type PartialIDCircuit struct {
+	FromBytes  LinkageID
+	Bytes      []byte
+	BitsPerVar int
+}
+
+func (c *PartialIDCircuit) Define(api frontend.API) error {
+	fromU8s := LinkageIDFromU8s(api, uints.NewU8Array(c.Bytes), c.BitsPerVar)
+	err := fromU8s.AssertIsEqual(api, c.FromBytes)
+	if err != nil {
+		return err
+	}
+	c.FromBytes.AssertIsInRange(api)
+
+	u8s := c.FromBytes.ToU8s(api)
+	if len(u8s) != len(c.Bytes) {
+		return ErrIDShapeMismatch
+	}
+	for i := 0; i < len(u8s); i++ {
+		api.AssertIsEqual(u8s[i].Val, c.Bytes[i])
+	}
+	return nil
+}
+
+func TestLinkageIDPartialTopValue(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// a 160-bit address on 128-bit values has a top value of 32 bits
+	address, err := hex.DecodeString("dac17f958d2ee523a2206206994597c13d831ec7")
+	assert.NoError(err)
+	id := LinkageIDFromBytes(address, 128)
+	assert.Equal(2, len(id.Vals))
+	assert.Equal(160, id.NbBits)
+	assert.Equal([]int{32, 128}, id.valBits())
+	joined, err := joinIDValues(splitIDBytes(address, 128), len(address), 128)
+	assert.NoError(err)
+	assert.Equal(LinkageIDBytes(address), joined)
+
+	for _, bitsPerVar := range []int{128, 64, 60, 20} {
+		id := LinkageIDFromBytes(address, bitsPerVar)
+		circuit := PartialIDCircuit{
+			FromBytes:  PlaceholderLinkageIDOfBits(160, bitsPerVar),
+			Bytes:      address,
+			BitsPerVar: bitsPerVar,
+		}
+		assert.Equal(len(id.Vals), len(circuit.FromBytes.Vals))
+		assert.NoError(test.IsSolved(&circuit, &PartialIDCircuit{FromBytes: id}, ecc.BN254.ScalarField()))
+	}
+
+	// values of a number of bits not a multiple of 8 never take whole bytes, e.g. a 192-bit id on 60-bit values has a
+	// top value of 12 bits
+	data, err := hex.DecodeString("843d12c93f9079e0d63a6101c31ac8a7eda3b78d6c4ea5b6")
+	assert.NoError(err)
+	for _, c := range []struct {
+		nbBytes int
+		valBits []int
+	}{
+		{24, []int{12, 60, 60, 60}},
+		{15, []int{60, 60}},
+	} {
+		id := LinkageIDFromBytes(data[:c.nbBytes], 60)
+		assert.Equal(c.valBits, id.valBits())
+		assert.Equal(c.valBits, idLayout(c.nbBytes, 60))
+		circuit := PartialIDCircuit{
+			FromBytes:  PlaceholderLinkageIDOfBits(c.nbBytes*8, 60),
+			Bytes:      data[:c.nbBytes],
+			BitsPerVar: 60,
+		}
+		assert.Equal(len(c.valBits), len(circuit.FromBytes.Vals))
+		assert.NoError(test.IsSolved(&circuit, &PartialIDCircuit{FromBytes: id}, ecc.BN254.ScalarField()))
+	}
+	assert.Equal([]int{64, 64, 64}, idLayout(24, 64))
+
+	// a 254-bit field element on 128-bit values has a top value of 126 bits
+	element, err := hex.DecodeString("2a05a97cf39df75d65bc8aa2bd2e33a7d6f6e4b43c1b62a7c71ab4d1f85a0c1e")
+	assert.NoError(err)
+	circuit := PartialIDCircuit{
+		FromBytes:  PlaceholderLinkageIDOfBits(254, 128),
+		Bytes:      element,
+		BitsPerVar: 128,
+	}
+	assert.Equal(254, circuit.FromBytes.NbBits)
+	assert.NoError(test.IsSolved(&circuit, &PartialIDCircuit{FromBytes: LinkageIDFromBytes(element, 128)}, ecc.BN254.ScalarField()))
+
+	element[0] |= 0x40
+	err = test.IsSolved(&circuit, &PartialIDCircuit{FromBytes: LinkageIDFromBytes(element, 128)}, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
@@ -11,10 +11,28 @@ type MultiUnit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2
 	BeginID          LinkageID                      `gnark:",public"`
 	EndID            LinkageID                      `gnark:",public"`
 	PlaceHolderFps   []common_utils.FingerPrint[FR] `gnark:",public"` // so that Unit could share the same witness alignment with Recursive
+	Count            ChainCount                     `gnark:",public"` // optional, empty unless counting is enabled
 	NbPlaceHolderFps int
+	NbLinks          int     // number of links proved by the unit, only used when counting is enabled
+	Padding          Padding // see NewPadding
 }
 
 func (c *MultiUnit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginID.Vals[0])
+	if err != nil {
+		return err
+	}
+
+	if c.Count.Enabled() {
+		api.AssertIsEqual(c.Count[0], c.NbLinks)
+	}
+	// a partial top value could otherwise hold more bits than the id has
+	if c.BeginID.NbBits != 0 {
+		c.BeginID.AssertIsInRange(api)
+	}
+	if c.EndID.NbBits != 0 {
+		c.EndID.AssertIsInRange(api)
+	}
 	return nil
 }
 
@@ -46,6 +64,25 @@ func NewMultiUnitAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2
 	}
 }
 
+// NewMultiUnitCircuitWithCount is the same as NewMultiUnitCircuit except that the unit exposes a ChainCount of nbLinks.
+func NewMultiUnitCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal, nbPlaceHolderFps, nbLinks int,
+) *MultiUnit[FR, G1El, G2El, GtEl] {
+	unit := NewMultiUnitCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, nbPlaceHolderFps)
+	unit.Count = PlaceholderChainCount(true)
+	unit.NbLinks = nbLinks
+	return unit
+}
+
+func NewMultiUnitAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	beginId, endId LinkageIDBytes, bitsPerIdVal int,
+	nbHolders int, nbLinks int,
+) *MultiUnit[FR, G1El, G2El, GtEl] {
+	unit := NewMultiUnitAssignment[FR, G1El, G2El, GtEl](beginId, endId, bitsPerIdVal, nbHolders)
+	unit.Count = ChainCountOf(uint64(nbLinks))
+	return unit
+}
+
 type Unit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
 	*MultiUnit[FR, G1El, G2El, GtEl]
 }
@@ -0,0 +1,204 @@
+package chainark
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/recursion/plonk"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+// UnitHybridCircuit extends the chain by a unit proof and a UnitCore component in one step, chaining
+// BeginID -> RelayID -> CompBeginID -> EndID. The first proof could be a unit proof or a recursive proof, the second
+// one must be a unit proof, while ThirdComp is verified in circuit, e.g. for a tail shorter than any unit circuit.
+type UnitHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	BeginID     LinkageID `gnark:",public"`
+	RelayID     LinkageID
+	CompBeginID LinkageID
+	EndID       LinkageID `gnark:",public"`
+
+	SelfFps []common_utils.FingerPrint[FR] `gnark:",public"`
+	Count   ChainCount                     `gnark:",public"` // optional, enabled iff the unit circuits have one
+
+	FirstVKey    plonk.VerifyingKey[FR, G1El, G2El]
+	FirstProof   plonk.Proof[FR, G1El, G2El]
+	FirstWitness plonk.Witness[FR]
+
+	SecondVKey    plonk.VerifyingKey[FR, G1El, G2El]
+	SecondProof   plonk.Proof[FR, G1El, G2El]
+	SecondWitness plonk.Witness[FR]
+
+	ThirdComp UnitCore[FR, G1El, G2El, GtEl]
+
+	// constant values passed from outside
+	ValidUnitFps []common_utils.FingerPrintBytes
+	NbSelfFps    int
+	Padding      Padding // see NewPadding
+
+	optimizedSize uint64 // see assertProofs
+}
+
+func (c *UnitHybridCircuit[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	err := c.Padding.Define(api, c.BeginID.Vals[0])
+	if err != nil {
+		return err
+	}
+
+	// verify the first vkey
+	rp := recursiveProof[FR, G1El, G2El, GtEl]{
+		beginID:   c.BeginID,
+		endID:     c.RelayID,
+		nbSelfFps: c.NbSelfFps,
+	}
+	err = rp.assertRelations(api, c.FirstVKey, c.FirstWitness, c.SelfFps, c.ValidUnitFps)
+	if err != nil {
+		return err
+	}
+
+	// verify the second vkey
+	secondFp, err := common_utils.InCircuitFingerPrint[FR, G1El, G2El](api, &c.SecondVKey)
+	if err != nil {
+		return err
+	}
+	assertFpInSet[FR](api, secondFp, c.ValidUnitFps)
+
+	assertIds[FR](api, c.BeginID, c.RelayID, c.FirstWitness.Public)
+	assertIds[FR](api, c.RelayID, c.CompBeginID, c.SecondWitness.Public)
+
+	if c.Count.Enabled() {
+		nbLinks, err := getNbLinks(c.ThirdComp)
+		if err != nil {
+			return err
+		}
+		assertCountSum[FR](api, c.Count, len(c.BeginID.Vals), c.NbSelfFps, nbLinks, c.FirstWitness.Public, c.SecondWitness.Public)
+	}
+
+	err = assertProofs[FR, G1El, G2El, GtEl](api, c.optimizedSize,
+		[]plonk.VerifyingKey[FR, G1El, G2El]{c.FirstVKey, c.SecondVKey},
+		[]plonk.Proof[FR, G1El, G2El]{c.FirstProof, c.SecondProof},
+		[]plonk.Witness[FR]{c.FirstWitness, c.SecondWitness})
+	if err != nil {
+		return err
+	}
+
+	// linking compBeginId to endId
+	err = c.CompBeginID.AssertIsEqual(api, c.ThirdComp.GetBeginID())
+	if err != nil {
+		return err
+	}
+	err = c.EndID.AssertIsEqual(api, c.ThirdComp.GetEndID())
+	if err != nil {
+		return err
+	}
+
+	return c.ThirdComp.Define(api)
+}
+
+func NewUnitHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	opt ...bool) (*UnitHybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newUnitHybridCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, extraComp, false, opt)
+}
+
+// NewUnitHybridCircuitWithCount is the same as NewUnitHybridCircuit for unit circuits exposing a ChainCount, see
+// NewMultiUnitCircuitWithCount.
+func NewUnitHybridCircuitWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	opt ...bool) (*UnitHybridCircuit[FR, G1El, G2El, GtEl], error) {
+	return newUnitHybridCircuit[FR, G1El, G2El, GtEl](nbIdVals, bitsPerIdVal, ccsUnit, unitFpBytes, nbSelfFps, extraComp, true, opt)
+}
+
+func newUnitHybridCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	nbIdVals, bitsPerIdVal int,
+	ccsUnit constraint.ConstraintSystem,
+	unitFpBytes []common_utils.FingerPrintBytes, nbSelfFps int,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	counted bool, opt []bool) (*UnitHybridCircuit[FR, G1El, G2El, GtEl], error) {
+
+	if nbSelfFps <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrBadSelfFpCount, nbSelfFps)
+	}
+	selfFps := make([]common_utils.FingerPrint[FR], nbSelfFps)
+	count, err := unitCount(ccsUnit.GetNbPublicVariables(), nbIdVals, nbSelfFps, counted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnitHybridCircuit[FR, G1El, G2El, GtEl]{
+		BeginID:     PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		RelayID:     PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		CompBeginID: PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+		EndID:       PlaceholderLinkageID(nbIdVals, bitsPerIdVal),
+
+		SelfFps: selfFps,
+		Count:   count,
+
+		FirstVKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccsUnit),
+		FirstProof:   plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit),
+		FirstWitness: plonk.PlaceholderWitness[FR](ccsUnit),
+
+		SecondVKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccsUnit),
+		SecondProof:   plonk.PlaceholderProof[FR, G1El, G2El](ccsUnit),
+		SecondWitness: plonk.PlaceholderWitness[FR](ccsUnit),
+
+		ThirdComp: extraComp,
+
+		ValidUnitFps:  unitFpBytes,
+		NbSelfFps:     nbSelfFps,
+		optimizedSize: optimizedSize(ccsUnit, opt),
+	}, nil
+}
+
+func NewUnitHybridAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	firstVkey, secondVkey plonk.VerifyingKey[FR, G1El, G2El],
+	firstProof, secondProof plonk.Proof[FR, G1El, G2El],
+	firstWitness, secondWitness plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, compBeginID, endID LinkageID,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+) *UnitHybridCircuit[FR, G1El, G2El, GtEl] {
+	return &UnitHybridCircuit[FR, G1El, G2El, GtEl]{
+		BeginID:     beginID,
+		RelayID:     relayID,
+		CompBeginID: compBeginID,
+		EndID:       endID,
+
+		SelfFps: recursiveFps,
+
+		FirstVKey:    firstVkey,
+		FirstProof:   firstProof,
+		FirstWitness: firstWitness,
+
+		SecondVKey:    secondVkey,
+		SecondProof:   secondProof,
+		SecondWitness: secondWitness,
+
+		ThirdComp: extraComp,
+	}
+}
+
+// NewUnitHybridAssignmentWithCount is the same as NewUnitHybridAssignment for a circuit created by
+// NewUnitHybridCircuitWithCount, count being the sum of the counts of both unit proofs plus the links of extraComp.
+func NewUnitHybridAssignmentWithCount[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	firstVkey, secondVkey plonk.VerifyingKey[FR, G1El, G2El],
+	firstProof, secondProof plonk.Proof[FR, G1El, G2El],
+	firstWitness, secondWitness plonk.Witness[FR],
+	recursiveFps []common_utils.FingerPrint[FR],
+	beginID, relayID, compBeginID, endID LinkageID,
+	extraComp UnitCore[FR, G1El, G2El, GtEl],
+	count uint64,
+) *UnitHybridCircuit[FR, G1El, G2El, GtEl] {
+	ret := NewUnitHybridAssignment[FR, G1El, G2El, GtEl](
+		firstVkey, secondVkey, firstProof, secondProof, firstWitness, secondWitness, recursiveFps, beginID, relayID, compBeginID, endID, extraComp)
+	ret.Count = ChainCountOf(count)
+	return ret
+}
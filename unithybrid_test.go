@@ -0,0 +1,42 @@
+package chainark
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+func TestUnitHybrid2Chain(t *testing.T) {
+	assert := test.NewAssert(t)
+	unit := setupTestUnit2Chain(assert, 0)
+	ids := unit.ids
+	first := unit.prove(assert, unit.link(0))
+	second := unit.prove(assert, unit.link(1))
+
+	assign := func(compBeginID LinkageIDBytes) *UnitHybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT] {
+		return NewUnitHybridAssignment[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			first.vk, second.vk, first.proof, second.proof, first.witness, second.witness,
+			[]common_utils.FingerPrint[sw_bls12377.ScalarField]{unit.recursiveFp()},
+			LinkageIDFromBytes(ids[0], 128), LinkageIDFromBytes(ids[1], 128),
+			LinkageIDFromBytes(compBeginID, 128), LinkageIDFromBytes(ids[3], 128),
+			&testComp2Chain{BeginID: LinkageIDFromBytes(compBeginID, 128), EndID: LinkageIDFromBytes(ids[3], 128)})
+	}
+
+	for _, optimization := range []bool{false, true} {
+		circuit, err := NewUnitHybridCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT](
+			2, 128, unit.ccs, []common_utils.FingerPrintBytes{unit.fp}, 1,
+			&testComp2Chain{BeginID: PlaceholderLinkageID(2, 128), EndID: PlaceholderLinkageID(2, 128)},
+			optimization)
+		assert.NoError(err)
+
+		err = test.IsSolved(circuit, assign(ids[2]), ecc.BW6_761.ScalarField())
+		assert.NoError(err)
+
+		// the component must start where the unit proof ends
+		err = test.IsSolved(circuit, assign(ids[1]), ecc.BW6_761.ScalarField())
+		assert.Error(err)
+	}
+}
@@ -3,7 +3,6 @@ package chainark
 import (
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/math/emulated"
-	"github.com/consensys/gnark/std/recursion/plonk"
 
 	common_utils "github.com/lightec-xyz/common/utils"
 )
@@ -23,11 +22,20 @@ func TestIDWitness[FR emulated.FieldParams](
 func assertIds[FR emulated.FieldParams](
 	api frontend.API,
 	beginId, endId LinkageID,
-	witness plonk.Witness[FR],
+	witnessValues []emulated.Element[FR],
 ) {
 	nbVars := len(beginId.Vals)
-	AssertIDWitness(api, beginId, witness.Public[:nbVars], uint(beginId.BitsPerVar))
-	AssertIDWitness(api, endId, witness.Public[nbVars:nbVars*2], uint(endId.BitsPerVar))
+	AssertIDWitness(api, beginId, witnessValues[:nbVars], uint(beginId.BitsPerVar))
+	AssertIDWitness(api, endId, witnessValues[nbVars:nbVars*2], uint(endId.BitsPerVar))
+}
+
+// same as common_utils.TestFpInSet, but without requiring the fingerprints to fit in FR
+func testFpInSet[FR emulated.FieldParams](api frontend.API, fp frontend.Variable, fpSet []common_utils.FingerPrintBytes) frontend.Variable {
+	return common_utils.TestFpInFpSet[FR](api, fp, FingerPrintsOf[FR](fpSet))
+}
+
+func assertFpInSet[FR emulated.FieldParams](api frontend.API, fp frontend.Variable, fpSet []common_utils.FingerPrintBytes) {
+	api.AssertIsEqual(testFpInSet[FR](api, fp, fpSet), 1)
 }
 
 func GetPlaceholderFp() common_utils.FingerPrintBytes {
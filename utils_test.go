@@ -34,9 +34,7 @@ func (c *idTestCircuit) Define(api frontend.API) error {
 	AssertIDWitness[sw_bn254.ScalarField](api, c.Id, idEles[:], 128)
 
 	id := RetrieveIDFromElements(api, idEles[:], 128)
-	id.AssertIsEqual(api, c.Id)
-
-	return nil
+	return id.AssertIsEqual(api, c.Id)
 }
 
 func newElementFromU128(field *emulated.Field[emparams.BN254Fr], api frontend.API, v []byte) emulated.Element[emparams.BN254Fr] {
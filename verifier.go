@@ -1,12 +1,15 @@
 package chainark
 
 import (
+	"fmt"
+
 	native_plonk "github.com/consensys/gnark/backend/plonk"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra"
 	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/rangecheck"
 	"github.com/consensys/gnark/std/recursion/plonk"
 	common_utils "github.com/lightec-xyz/common/utils"
 )
@@ -21,11 +24,14 @@ type Verifier[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2E
 	NbIdVars     int
 	NbFpVars     int
 	NbSelfFps    int
+	MinCount     uint64 // if not zero, the inner proof must have a ChainCount of at least MinCount
+
+	optimizedSize uint64 // see assertProofs and NewOptimizedVerifierCircuit
 }
 
 func (c *Verifier[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
 	if c.NbSelfFps != len(c.VkeyFpsBytes) {
-		panic("length mismatch")
+		return fmt.Errorf("%w: %v vs %v vkey fingerprints", ErrBadSelfFpCount, c.NbSelfFps, len(c.VkeyFpsBytes))
 	}
 
 	vkeyFp, err := common_utils.InCircuitFingerPrint[FR, G1El, G2El](api, &c.VKey)
@@ -33,11 +39,11 @@ func (c *Verifier[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
 		return err
 	}
 
-	common_utils.AssertFpInSet[FR](api, vkeyFp, c.VkeyFpsBytes)
+	assertFpInSet[FR](api, vkeyFp, c.VkeyFpsBytes)
 
 	vkeyFps := make([]common_utils.FingerPrint[FR], len(c.VkeyFpsBytes))
 	for i := 0; i < len(c.VkeyFpsBytes); i++ {
-		vkeyFps[i] = common_utils.FingerPrintFromBytes[FR](c.VkeyFpsBytes[i])
+		vkeyFps[i] = FingerPrintOf[FR](c.VkeyFpsBytes[i])
 	}
 
 	initialOffset := c.NbIdVars * 2
@@ -46,21 +52,38 @@ func (c *Verifier[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
 	setTest := TestRecursiveFps[FR](api, c.Witness, vkeyFps, initialOffset, nbFpVars, c.NbSelfFps)
 	api.AssertIsEqual(1, setTest)
 
-	verifier, err := plonk.NewVerifier[FR, G1El, G2El, GtEl](api)
-	if err != nil {
-		return err
+	if c.MinCount > 0 {
+		count := RetrieveCount[FR](api, c.Witness, c.NbIdVars, nbFpVars, c.NbSelfFps)
+		// count is no more than 64 bits, so is the difference unless count < MinCount
+		rcheck := rangecheck.New(api)
+		rcheck.Check(api.Sub(count, c.MinCount), nbCountBits)
 	}
 
-	return verifier.AssertProof(c.VKey, c.Proof, c.Witness, plonk.WithCompleteArithmetic())
+	return assertProofs[FR, G1El, G2El, GtEl](api, c.optimizedSize,
+		[]plonk.VerifyingKey[FR, G1El, G2El]{c.VKey},
+		[]plonk.Proof[FR, G1El, G2El]{c.Proof},
+		[]plonk.Witness[FR]{c.Witness})
 }
 
 func NewVerifierCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
 	ccs constraint.ConstraintSystem,
 	vkeyFpsBytes []common_utils.FingerPrintBytes,
 	nbIdVars, nbFpVars, nbSelfFps int,
+	minCount ...uint64,
 ) (*Verifier[FR, G1El, G2El, GtEl], error) {
 	if len(vkeyFpsBytes) != nbSelfFps {
-		panic("wrong number of nbSelfFps or vkeyFpsBytes")
+		return nil, fmt.Errorf("%w: %v vs %v vkey fingerprints", ErrBadSelfFpCount, nbSelfFps, len(vkeyFpsBytes))
+	}
+	minCnt := uint64(0)
+	if len(minCount) != 0 {
+		minCnt = minCount[0]
+	}
+	if minCnt > 0 {
+		// the inner proofs must expose a ChainCount
+		_, err := unitCount(ccs.GetNbPublicVariables(), nbIdVars, nbFpVars*nbSelfFps, true)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return &Verifier[FR, G1El, G2El, GtEl]{
 		VKey:    plonk.PlaceholderVerifyingKey[FR, G1El, G2El](ccs),
@@ -71,9 +94,26 @@ func NewVerifierCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El a
 		NbIdVars:     nbIdVars,
 		NbFpVars:     nbFpVars,
 		NbSelfFps:    nbSelfFps,
+		MinCount:     minCnt,
 	}, nil
 }
 
+// NewOptimizedVerifierCircuit is the same as NewVerifierCircuit with the optimization turned on, ccs being the circuit
+// of the recursive and hybrid circuits created with the optimization, all of them having the same domain size.
+func NewOptimizedVerifierCircuit[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
+	ccs constraint.ConstraintSystem,
+	vkeyFpsBytes []common_utils.FingerPrintBytes,
+	nbIdVars, nbFpVars, nbSelfFps int,
+	minCount ...uint64,
+) (*Verifier[FR, G1El, G2El, GtEl], error) {
+	c, err := NewVerifierCircuit[FR, G1El, G2El, GtEl](ccs, vkeyFpsBytes, nbIdVars, nbFpVars, nbSelfFps, minCount...)
+	if err != nil {
+		return nil, err
+	}
+	c.optimizedSize = DomainSize(ccs)
+	return c, nil
+}
+
 func NewVerifierAssignment[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT](
 	vkey native_plonk.VerifyingKey,
 	proof native_plonk.Proof,
@@ -0,0 +1,61 @@
+package chainark
+
+import (
+	"github.com/consensys/gnark/backend"
+	native_groth16 "github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+/**
+ * Groth16Wrapper proves a Verifier, or any circuit embedding one such as a solidity wrapper, with Groth16: the inner
+ * layers keep PLONK and its universal setup, while the final proof is cheaper to verify on chain. The Verifier is
+ * compiled with r1cs.NewBuilder, and verifies PLONK proofs with the same fingerprints as when compiled for PLONK.
+ *
+ * Unlike PLONK, Groth16 needs a setup per circuit: UnsafeSetupGroth16Wrapper runs it locally, which is only safe for
+ * tests. For production, run a ceremony over the circuit compiled by CompileGroth16Wrapper and fill in the keys.
+ */
+type Groth16Wrapper struct {
+	Ccs constraint.ConstraintSystem
+	Pk  native_groth16.ProvingKey
+	Vk  native_groth16.VerifyingKey
+}
+
+// CompileGroth16Wrapper compiles circuit with r1cs.NewBuilder, in the field of the circuits verifying proofs over FR.
+func CompileGroth16Wrapper[FR emulated.FieldParams](circuit frontend.Circuit) (constraint.ConstraintSystem, error) {
+	return frontend.Compile(outerField[FR](), r1cs.NewBuilder, circuit)
+}
+
+// UnsafeSetupGroth16Wrapper compiles circuit with CompileGroth16Wrapper, then runs the Groth16 setup locally.
+func UnsafeSetupGroth16Wrapper[FR emulated.FieldParams](circuit frontend.Circuit) (*Groth16Wrapper, error) {
+	ccs, err := CompileGroth16Wrapper[FR](circuit)
+	if err != nil {
+		return nil, err
+	}
+	pk, vk, err := native_groth16.Setup(ccs)
+	if err != nil {
+		return nil, err
+	}
+	return &Groth16Wrapper{Ccs: ccs, Pk: pk, Vk: vk}, nil
+}
+
+// Prove proves assignment with Groth16, returning the proof along with its public witness. Pass
+// solidity.WithProverTargetSolidityVerifier(backend.GROTH16) in opts for a proof verified on chain.
+func (w *Groth16Wrapper) Prove(assignment frontend.Circuit, opts ...backend.ProverOption) (native_groth16.Proof, witness.Witness, error) {
+	wit, err := frontend.NewWitness(assignment, w.Ccs.Field())
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := native_groth16.Prove(w.Ccs, w.Pk, wit, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubWitness, err := wit.Public()
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, pubWitness, nil
+}
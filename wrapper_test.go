@@ -0,0 +1,45 @@
+package chainark
+
+import (
+	"os"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	native_groth16 "github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	"github.com/consensys/gnark/test"
+	common_utils "github.com/lightec-xyz/common/utils"
+)
+
+func TestGroth16Wrapper(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	unit := setupTestUnit(assert, 1, 0)
+
+	// a PLONK unit proof carrying its own fingerprint, as a recursive proof would
+	proof, pubWitness := unit.proveNative(assert, unit.link(0, unit.fp))
+
+	circuit, err := NewVerifierCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](
+		unit.ccs, []common_utils.FingerPrintBytes{unit.fp}, 2, 1, 1)
+	assert.NoError(err)
+	verifierAssignment, err := NewVerifierAssignment[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](unit.vk, proof, pubWitness)
+	assert.NoError(err)
+
+	// the verifier compiled with r1cs.NewBuilder accepts the PLONK proof
+	wrapperCcs, err := CompileGroth16Wrapper[sw_bn254.ScalarField](circuit)
+	assert.NoError(err)
+	wrapperWitness, err := frontend.NewWitness(verifierAssignment, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	assert.NoError(wrapperCcs.IsSolved(wrapperWitness))
+
+	// the groth16 setup and proof of the wrapper take about half an hour on a single core
+	if os.Getenv("CHAINARK_LONG_TESTS") == "" {
+		t.Skip("set CHAINARK_LONG_TESTS to prove the wrapper with groth16")
+	}
+	wrapper, err := UnsafeSetupGroth16Wrapper[sw_bn254.ScalarField](circuit)
+	assert.NoError(err)
+	wrapperProof, wrapperPubWitness, err := wrapper.Prove(verifierAssignment)
+	assert.NoError(err)
+	assert.NoError(native_groth16.Verify(wrapperProof, wrapper.Vk, wrapperPubWitness))
+}